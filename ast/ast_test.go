@@ -26,3 +26,24 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong: got=%q", program.String())
 	}
 }
+
+func TestMacroLiteralString(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return &Identifier{
+			Token: token.Token{Type: token.Ident, Literal: name},
+			Value: name,
+		}
+	}
+	macro := &MacroLiteral{
+		Token:      token.Token{Type: token.Macro, Literal: "macro"},
+		Parameters: []*Identifier{ident("x"), ident("y")},
+		Body: &BlockStatement{
+			Statements: []Statement{
+				&ExpressionStatement{Expression: ident("x")},
+			},
+		},
+	}
+	if macro.String() != "macro(x, y) x" {
+		t.Errorf("macro.String() wrong: got=%q", macro.String())
+	}
+}
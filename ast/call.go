@@ -7,11 +7,14 @@ import (
 	"github.com/rtfb/tarsier/token"
 )
 
-// CallExpression represents the call of a function.
+// CallExpression represents the call of a function. A parenthesized one
+// (`(f(a))`) is applied to a piped value instead of getting it as the first
+// argument.
 type CallExpression struct {
-	Token     token.Token // the '(' token
-	Function  Expression
-	Arguments []Expression
+	Token         token.Token // the '(' token
+	Function      Expression
+	Arguments     []Expression
+	Parenthesized bool
 }
 
 func (ce *CallExpression) expressionNode() {
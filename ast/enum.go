@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/rtfb/tarsier/token"
+)
+
+// EnumStatement is the AST subtree containing an enum declaration, e.g.
+// `enum Shape { Circle(r), Rect(w, h), Empty }`.
+type EnumStatement struct {
+	Token    token.Token // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+// EnumVariant is a single variant of an enum declaration. A variant without
+// fields is a unit variant, a value rather than a constructor.
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (es *EnumStatement) statementNode() {}
+
+// TokenLiteral implements Node.
+func (es *EnumStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+// String implements Node.
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+	variants := make([]string, len(es.Variants))
+	for i, v := range es.Variants {
+		variants[i] = v.String()
+	}
+	out.WriteString("enum ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" {")
+	if len(variants) > 0 {
+		out.WriteString(" " + strings.Join(variants, ", ") + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// String renders the variant the way it's declared.
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+	fields := make([]string, len(ev.Fields))
+	for i, f := range ev.Fields {
+		fields[i] = f.String()
+	}
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
@@ -10,6 +10,7 @@ import (
 // FunctionLiteral represents the fn expression.
 type FunctionLiteral struct {
 	Token      token.Token // the 'fn' token
+	Name       string      // empty for anonymous functions
 	Parameters []*Identifier
 	Body       *BlockStatement
 }
@@ -30,9 +31,33 @@ func (fl *FunctionLiteral) String() string {
 		params[i] = p.String()
 	}
 	out.WriteString(fl.TokenLiteral())
+	if fl.Name != "" {
+		out.WriteString(" " + fl.Name)
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
 	out.WriteString(fl.Body.String())
 	return out.String()
 }
+
+// FunctionStatement is the AST subtree containing a named function
+// declaration, e.g. `fn add(a, b) { a + b }`.
+type FunctionStatement struct {
+	Token    token.Token // the 'fn' token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fs *FunctionStatement) statementNode() {
+}
+
+// TokenLiteral implements Node.
+func (fs *FunctionStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+// String implements Node.
+func (fs *FunctionStatement) String() string {
+	return fs.Function.String()
+}
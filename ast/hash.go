@@ -7,10 +7,17 @@ import (
 	"github.com/rtfb/tarsier/token"
 )
 
-// HashLiteral is the AST subtree containing a hash map literal.
+// HashPair is a single key: value entry of a hash literal.
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral is the AST subtree containing a hash map literal. The pairs are
+// kept in the order they appear in the source.
 type HashLiteral struct {
 	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Pairs []HashPair
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -24,8 +31,8 @@ func (hl *HashLiteral) TokenLiteral() string {
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := make([]string, 0, len(hl.Pairs))
-	for k, v := range hl.Pairs {
-		pairs = append(pairs, k.String()+":"+v.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
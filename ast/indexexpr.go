@@ -7,10 +7,14 @@ import (
 )
 
 // IndexExpression is the AST subtree containing an array indexing expression.
+// An optional one (`a?.[i]`) evaluates to null instead of indexing a null,
+// skipping the indexes and calls chained after it too (`a?.[i][j]`).
+// Member access (`a.field`, `a?.field`) is parsed as indexing with a string.
 type IndexExpression struct {
-	Token token.Token // The '[' token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The '[', '.' or '?.' token
+	Left     Expression
+	Index    Expression
+	Optional bool
 }
 
 func (ie *IndexExpression) expressionNode() {}
@@ -25,6 +29,9 @@ func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
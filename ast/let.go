@@ -2,20 +2,31 @@ package ast
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/rtfb/tarsier/token"
 )
 
-// LetStatement is the AST subtree containing a let statement.
+// LetStatement is the AST subtree containing a let statement. It also
+// represents const statements, which only differ in the token.
+//
+// A statement unpacking a tuple, like `let (q, r) = divmod(7, 2);`, has Names
+// instead of Name.
 type LetStatement struct {
-	Token token.Token
+	Token token.Token // the 'let' or 'const' token
 	Name  *Identifier
+	Names []*Identifier
 	Value Expression
 }
 
 func (ls *LetStatement) statementNode() {
 }
 
+// IsConst tells whether the statement declares a constant.
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.Const
+}
+
 // TokenLiteral implements Node.
 func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
@@ -25,7 +36,15 @@ func (ls *LetStatement) TokenLiteral() string {
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Name != nil {
+		out.WriteString(ls.Name.String())
+	} else {
+		names := make([]string, len(ls.Names))
+		for i, name := range ls.Names {
+			names[i] = name.String()
+		}
+		out.WriteString("(" + strings.Join(names, ", ") + ")")
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
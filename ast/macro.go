@@ -31,7 +31,7 @@ func (ml *MacroLiteral) String() string {
 	out.WriteString(ml.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString("( ")
+	out.WriteString(") ")
 	out.WriteString(ml.Body.String())
 	return out.String()
 }
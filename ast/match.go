@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/rtfb/tarsier/token"
+)
+
+// MatchExpression is the AST subtree containing a match expression. It
+// evaluates the body of the first arm whose pattern matches the value.
+type MatchExpression struct {
+	Token token.Token // the 'match' token
+	Value Expression
+	Arms  []MatchArm
+}
+
+// MatchArm is a single `pattern if (guard) => body` arm of a match expression.
+//
+// Patterns are represented by the expressions they look like: literals,
+// identifiers (which bind the value, `_` matches anything without binding it),
+// and array, tuple and hash literals made of nested patterns. The last element of an
+// array pattern can be a SpreadExpression of an identifier, binding the rest
+// of the array. Struct and enum variant patterns, like `Circle(r)`, are calls
+// with patterns of the fields as the arguments. An identifier bound to a unit
+// enum variant matches that variant instead of binding the value.
+type MatchArm struct {
+	Pattern Expression
+	Guard   Expression // nil if the arm has no guard
+	Body    *BlockStatement
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+// TokenLiteral implements Node.
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+// String implements Node.
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+	arms := make([]string, len(me.Arms))
+	for i, arm := range me.Arms {
+		arms[i] = arm.Pattern.String()
+		if arm.Guard != nil {
+			arms[i] += " if " + arm.Guard.String()
+		}
+		arms[i] += " => " + arm.Body.String()
+	}
+	out.WriteString("match (")
+	out.WriteString(me.Value.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+	return out.String()
+}
@@ -40,6 +40,12 @@ func Modify(node Node, modifier ModifierFunc) Node {
 		node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
 	case *LetStatement:
 		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *FunctionStatement:
+		node.Function, _ = Modify(node.Function, modifier).(*FunctionLiteral)
+	case *StructStatement:
+		for i := range node.Methods {
+			node.Methods[i], _ = Modify(node.Methods[i], modifier).(*FunctionLiteral)
+		}
 	case *FunctionLiteral:
 		for i := range node.Parameters {
 			node.Parameters[i], _ = Modify(node.Parameters[i], modifier).(*Identifier)
@@ -49,14 +55,25 @@ func Modify(node Node, modifier ModifierFunc) Node {
 		for i := range node.Elements {
 			node.Elements[i], _ = Modify(node.Elements[i], modifier).(Expression)
 		}
+	case *TupleLiteral:
+		for i := range node.Elements {
+			node.Elements[i], _ = Modify(node.Elements[i], modifier).(Expression)
+		}
+	case *SpreadExpression:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *MatchExpression:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+		for i, arm := range node.Arms {
+			if arm.Guard != nil {
+				node.Arms[i].Guard, _ = Modify(arm.Guard, modifier).(Expression)
+			}
+			node.Arms[i].Body, _ = Modify(arm.Body, modifier).(*BlockStatement)
+		}
 	case *HashLiteral:
-		newPairs := make(map[Expression]Expression, len(node.Pairs))
-		for key, val := range node.Pairs {
-			newKey, _ := Modify(key, modifier).(Expression)
-			newVal, _ := Modify(val, modifier).(Expression)
-			newPairs[newKey] = newVal
+		for i, pair := range node.Pairs {
+			node.Pairs[i].Key, _ = Modify(pair.Key, modifier).(Expression)
+			node.Pairs[i].Value, _ = Modify(pair.Value, modifier).(Expression)
 		}
-		node.Pairs = newPairs
 	}
 	return modifier(node)
 }
@@ -95,6 +95,44 @@ func TestModify(t *testing.T) {
 				Index: two(),
 			},
 		},
+		{
+			&MatchExpression{
+				Value: one(),
+				Arms: []MatchArm{
+					{
+						Pattern: &Identifier{Value: "x"},
+						Guard:   one(),
+						Body: &BlockStatement{
+							Statements: []Statement{
+								&ExpressionStatement{Expression: one()},
+							},
+						},
+					},
+				},
+			},
+			&MatchExpression{
+				Value: two(),
+				Arms: []MatchArm{
+					{
+						Pattern: &Identifier{Value: "x"},
+						Guard:   two(),
+						Body: &BlockStatement{
+							Statements: []Statement{
+								&ExpressionStatement{Expression: two()},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			&TupleLiteral{Elements: []Expression{one(), one()}},
+			&TupleLiteral{Elements: []Expression{two(), two()}},
+		},
+		{
+			&SpreadExpression{Value: one()},
+			&SpreadExpression{Value: two()},
+		},
 		{
 			&IfExpression{
 				Condition: one(),
@@ -177,6 +215,20 @@ func TestModify(t *testing.T) {
 				Elements: []Expression{two(), two()},
 			},
 		},
+		{
+			&HashLiteral{
+				Pairs: []HashPair{
+					{one(), one()},
+					{one(), one()},
+				},
+			},
+			&HashLiteral{
+				Pairs: []HashPair{
+					{two(), two()},
+					{two(), two()},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		modified := Modify(tt.input, turnOneIntoTwo)
@@ -185,22 +237,4 @@ func TestModify(t *testing.T) {
 			t.Errorf("not equal, got=%#v, want=%#v", modified, tt.want)
 		}
 	}
-	// test modification of hash literal:
-	hashLiteral := &HashLiteral{
-		Pairs: map[Expression]Expression{
-			one(): one(),
-			one(): one(),
-		},
-	}
-	Modify(hashLiteral, turnOneIntoTwo)
-	for key, val := range hashLiteral.Pairs {
-		key, _ := key.(*IntegerLiteral)
-		if key.Value != 2 {
-			t.Errorf("value is not %d, got=%d", 2, key.Value)
-		}
-		val, _ := val.(*IntegerLiteral)
-		if val.Value != 2 {
-			t.Errorf("value is not %d, got=%d", 2, key.Value)
-		}
-	}
 }
@@ -0,0 +1,22 @@
+package ast
+
+import (
+	"github.com/rtfb/tarsier/token"
+)
+
+// NullLiteral is the AST subtree containing the null literal.
+type NullLiteral struct {
+	Token token.Token // the 'null' token
+}
+
+func (nl *NullLiteral) expressionNode() {}
+
+// TokenLiteral implements Node.
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+// String implements Node.
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
@@ -0,0 +1,24 @@
+package ast
+
+import (
+	"github.com/rtfb/tarsier/token"
+)
+
+// SpreadExpression is the AST subtree containing a spread array (`...xs`) in
+// an array literal or in call arguments.
+type SpreadExpression struct {
+	Token token.Token // the '...' token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+
+// TokenLiteral implements Node.
+func (se *SpreadExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// String implements Node.
+func (se *SpreadExpression) String() string {
+	return "..." + se.Value.String()
+}
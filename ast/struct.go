@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/rtfb/tarsier/token"
+)
+
+// StructStatement is the AST subtree containing a struct declaration, e.g.
+// `struct Point { x, y }`.
+type StructStatement struct {
+	Token   token.Token // the 'struct' token
+	Name    *Identifier
+	Fields  []*Identifier
+	Methods []*FunctionLiteral // named function literals
+}
+
+func (ss *StructStatement) statementNode() {}
+
+// TokenLiteral implements Node.
+func (ss *StructStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+// String implements Node.
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+	fields := make([]string, 0, len(ss.Fields)+len(ss.Methods))
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+	for _, m := range ss.Methods {
+		fields = append(fields, m.String())
+	}
+	out.WriteString("struct ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" {")
+	if len(fields) > 0 {
+		out.WriteString(" " + strings.Join(fields, ", ") + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/rtfb/tarsier/token"
+)
+
+// TupleLiteral is the AST subtree containing a tuple literal, e.g. `(a, b)`.
+type TupleLiteral struct {
+	Token    token.Token // the '(' token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode() {}
+
+// TokenLiteral implements Node.
+func (tl *TupleLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+// String implements Node.
+func (tl *TupleLiteral) String() string {
+	var out bytes.Buffer
+	elements := make([]string, len(tl.Elements))
+	for i, el := range tl.Elements {
+		elements[i] = el.String()
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	if len(elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+	return out.String()
+}
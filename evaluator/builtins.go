@@ -2,39 +2,47 @@ package evaluator
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/rtfb/tarsier/object"
 )
 
-var builtins = map[string]*object.Builtin{
-	"len": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments, got=%d, want=1", len(args))
-			}
+var (
+	arrayType     = []object.Type{object.ObjTypeArray}
+	stringType    = []object.Type{object.ObjTypeString}
+	integerType   = []object.Type{object.ObjTypeInteger}
+	recordType    = []object.Type{object.ObjTypeRecord}
+	hashType      = []object.Type{object.ObjTypeHash}
+	callableTypes = []object.Type{object.ObjTypeFunction, object.ObjTypeBuiltin, object.ObjTypeStruct}
+)
+
+var coreBuiltins = []BuiltinDef{
+	{
+		Name:   "len",
+		Params: []Param{{"obj", []object.Type{object.ObjTypeString, object.ObjTypeArray, object.ObjTypeTuple}}},
+		Doc:    "Returns the length of a string, an array or a tuple.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
 			switch arg := args[0].(type) {
 			case *object.String:
 				return &object.Integer{
 					Value: int64(len(arg.Value)),
 				}
-			case *object.Array:
+			case *object.Tuple:
 				return &object.Integer{
 					Value: int64(len(arg.Elements)),
 				}
 			default:
-				return newError("argument to `len` not supported, got %s", args[0].Type())
+				return &object.Integer{
+					Value: int64(len(arg.(*object.Array).Elements)),
+				}
 			}
-			return Null
 		},
 	},
-	"first": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments, got=%d, want=1", len(args))
-			}
-			if args[0].Type() != object.ObjTypeArray {
-				return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
-			}
+	{
+		Name:   "first",
+		Params: []Param{{"arr", arrayType}},
+		Doc:    "Returns the first element of an array, or null if it's empty.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
 			arr := args[0].(*object.Array)
 			if len(arr.Elements) > 0 {
 				return arr.Elements[0]
@@ -42,14 +50,11 @@ var builtins = map[string]*object.Builtin{
 			return Null
 		},
 	},
-	"last": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments, got=%d, want=1", len(args))
-			}
-			if args[0].Type() != object.ObjTypeArray {
-				return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
-			}
+	{
+		Name:   "last",
+		Params: []Param{{"arr", arrayType}},
+		Doc:    "Returns the last element of an array, or null if it's empty.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
 			arr := args[0].(*object.Array)
 			length := len(arr.Elements)
 			if length > 0 {
@@ -58,50 +63,266 @@ var builtins = map[string]*object.Builtin{
 			return Null
 		},
 	},
-	"rest": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments, got=%d, want=1", len(args))
+	{
+		Name:   "rest",
+		Params: []Param{{"arr", arrayType}},
+		Doc:    "Returns a new array with all elements but the first one, or null if it's empty.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Rest()
 			}
-			if args[0].Type() != object.ObjTypeArray {
-				return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
+			return Null
+		},
+	},
+	{
+		Name:   "push",
+		Params: []Param{{"arr", arrayType}, {"el", nil}},
+		Doc:    "Returns a new array with el appended to the end of arr.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			return args[0].(*object.Array).Push(args[1])
+		},
+	},
+	{
+		Name:   "index_of",
+		Params: []Param{{"arr", arrayType}, {"el", nil}},
+		Doc:    "Returns the index of the first element of arr equal to el, or -1.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			return &object.Integer{
+				Value: int64(indexOf(args[0].(*object.Array), args[1])),
 			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				newElements := make([]object.Object, length-1, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &object.Array{
-					Elements: newElements,
+		},
+	},
+	{
+		Name:   "divmod",
+		Params: []Param{{"a", integerType}, {"b", integerType}},
+		Doc:    "Returns the tuple (a / b, a - a / b * b), the quotient and the remainder of a divided by b.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			a := args[0].(*object.Integer).Value
+			b := args[1].(*object.Integer).Value
+			if b == 0 {
+				return newError("division by zero")
+			}
+			return &object.Tuple{
+				Elements: []object.Object{
+					&object.Integer{Value: a / b},
+					&object.Integer{Value: a % b},
+				},
+			}
+		},
+	},
+	{
+		Name:   "is_null",
+		Params: []Param{{"obj", nil}},
+		Doc:    "Returns whether obj is null.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			return nativeBoolToBooleanObject(isNull(args[0]))
+		},
+	},
+	{
+		Name:     "puts",
+		Params:   []Param{{"objs", nil}},
+		Variadic: true,
+		Doc:      "Prints each argument on a separate line, using its __str__ method if it has one.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			for _, arg := range args {
+				str := displayString(arg, ctx.Env)
+				if isError(str) {
+					return str
 				}
+				fmt.Fprintln(ctx.Out, str.(*object.String).Value)
 			}
 			return Null
 		},
 	},
-	"push": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments, got=%d, want=2", len(args))
+	{
+		Name:   "doc",
+		Params: []Param{{"fn", []object.Type{object.ObjTypeBuiltin}}},
+		Doc:    "Returns the documentation of a builtin function.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			return &object.String{
+				Value: args[0].(*object.Builtin).Doc,
+			}
+		},
+	},
+	{
+		Name:   "type",
+		Params: []Param{{"obj", nil}},
+		Doc:    "Returns the name of the type of obj: the struct or enum name for records, the object type, e.g. INTEGER, otherwise.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			typeName := string(args[0].Type())
+			if record, ok := args[0].(*object.Record); ok {
+				typeName = record.Struct.Name
+				if record.Struct.Enum != nil {
+					typeName = record.Struct.Enum.Name
+				}
+			}
+			return &object.String{
+				Value: typeName,
+			}
+		},
+	},
+	{
+		Name:   "set",
+		Params: []Param{{"record", recordType}, {"field", stringType}, {"value", nil}},
+		Doc:    "Returns a copy of record with field set to value.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			record := args[0].(*object.Record)
+			field := args[1].(*object.String).Value
+			updated, ok := record.With(field, args[2])
+			if !ok {
+				return unknownFieldError(record, field)
+			}
+			return updated
+		},
+	},
+	{
+		Name:     "new",
+		Params:   []Param{{"proto", hashType}, {"args", nil}},
+		Variadic: true,
+		Doc:      "Creates an object with proto as its prototype, calling its init method with args. Returns what init returns, or the object if proto has no init.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			obj := &object.Hash{}
+			obj.Set(protoKey, args[0])
+			init, ok := findMethod(obj, "init")
+			if !ok {
+				if len(args) > 1 {
+					return newError("no init method to pass the arguments to")
+				}
+				return obj
+			}
+			return applyMethod(init, obj, args[1:], ctx.Env)
+		},
+	},
+	{
+		Name:   "map",
+		Params: []Param{{"arr", arrayType}, {"fn", callableTypes}},
+		Doc:    "Returns a new array with fn applied to each element of arr.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			arr := args[0].(*object.Array)
+			mapped := make([]object.Object, len(arr.Elements))
+			for i, el := range arr.Elements {
+				result := ctx.Apply(args[1], el)
+				if isError(result) {
+					return result
+				}
+				mapped[i] = result
 			}
-			if args[0].Type() != object.ObjTypeArray {
-				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
+			return &object.Array{
+				Elements: mapped,
 			}
+		},
+	},
+	{
+		Name:   "filter",
+		Params: []Param{{"arr", arrayType}, {"fn", callableTypes}},
+		Doc:    "Returns a new array with the elements of arr for which fn is truthy.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
+			filtered := []object.Object{}
+			for _, el := range arr.Elements {
+				result := ctx.Apply(args[1], el)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					filtered = append(filtered, el)
+				}
+			}
 			return &object.Array{
-				Elements: newElements,
+				Elements: filtered,
 			}
 		},
 	},
-	"puts": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+	{
+		Name:   "reduce",
+		Params: []Param{{"arr", arrayType}, {"initial", nil}, {"fn", callableTypes}},
+		Doc:    "Folds arr into a single value by calling fn(accumulated, el) for each element.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			arr := args[0].(*object.Array)
+			result := args[1]
+			for _, el := range arr.Elements {
+				result = ctx.Apply(args[2], result, el)
+				if isError(result) {
+					return result
+				}
+			}
+			return result
+		},
+	},
+	{
+		Name:   "sort_by",
+		Params: []Param{{"arr", arrayType}, {"fn", callableTypes}},
+		Doc:    "Returns a new array with the elements of arr stably sorted by the keys fn returns for them.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			arr := args[0].(*object.Array)
+			keys := make([]object.Object, len(arr.Elements))
+			for i, el := range arr.Elements {
+				key := ctx.Apply(args[1], el)
+				if isError(key) {
+					return key
+				}
+				keys[i] = key
+			}
+			indices := make([]int, len(arr.Elements))
+			for i := range indices {
+				indices[i] = i
+			}
+			var cmpErr *object.Error
+			sort.SliceStable(indices, func(i, j int) bool {
+				less, errObj := lessSortKeys(keys[indices[i]], keys[indices[j]])
+				if errObj != nil && cmpErr == nil {
+					cmpErr = errObj
+				}
+				return less
+			})
+			if cmpErr != nil {
+				return cmpErr
+			}
+			sorted := make([]object.Object, len(arr.Elements))
+			for i, idx := range indices {
+				sorted[i] = arr.Elements[idx]
+			}
+			return &object.Array{
+				Elements: sorted,
+			}
+		},
+	},
+	{
+		Name:   "each",
+		Params: []Param{{"arr", arrayType}, {"fn", callableTypes}},
+		Doc:    "Calls fn for each element of arr, returns null.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			arr := args[0].(*object.Array)
+			for _, el := range arr.Elements {
+				result := ctx.Apply(args[1], el)
+				if isError(result) {
+					return result
+				}
 			}
 			return Null
 		},
 	},
 }
+
+func init() {
+	if err := RegisterBuiltins("", coreBuiltins...); err != nil {
+		panic(err)
+	}
+}
+
+// lessSortKeys compares two keys produced by the `sort_by` callback. Only
+// integers and strings can be ordered.
+func lessSortKeys(a, b object.Object) (bool, *object.Error) {
+	switch a := a.(type) {
+	case *object.Integer:
+		if b, ok := b.(*object.Integer); ok {
+			return a.Value < b.Value, nil
+		}
+	case *object.String:
+		if b, ok := b.(*object.String); ok {
+			return a.Value < b.Value, nil
+		}
+	}
+	return false, newError("sort keys not comparable: %s and %s", a.Type(), b.Type())
+}
@@ -0,0 +1,26 @@
+package evaluator
+
+import (
+	"errors"
+
+	"github.com/rtfb/tarsier/object"
+)
+
+// Call applies a Tarsier function (or a builtin) to the given arguments. It
+// lets host Go code use functions obtained from a script as callbacks. An
+// error object produced by the call is converted to a Go error. Builtins called
+// this way run in the default, empty env.
+func Call(fn object.Object, args ...object.Object) (object.Object, error) {
+	if fn == nil {
+		return nil, errors.New("not a function: nil")
+	}
+	env := object.NewEnv()
+	if function, ok := fn.(*object.Function); ok {
+		env = function.Env
+	}
+	result := applyFunction(fn, args, env)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, errors.New(errObj.Message)
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/rtfb/tarsier/object"
+)
+
+func TestCall(t *testing.T) {
+	fn := testEval(t, "let x = 10; fn(a, b) { a * b + x }")
+	got, err := Call(fn, &object.Integer{Value: 2}, &object.Integer{Value: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testIntegerObject(t, got, 16)
+}
+
+func TestCallBuiltin(t *testing.T) {
+	got, err := Call(builtins["len"], &object.String{Value: "four"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testIntegerObject(t, got, 4)
+}
+
+func TestCallErrors(t *testing.T) {
+	tests := []struct {
+		fn      object.Object
+		args    []object.Object
+		wantMsg string
+	}{
+		{
+			testEval(t, "fn(a) { a + true }"),
+			[]object.Object{&object.Integer{Value: 1}},
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			testEval(t, "fn(a, b) { a }"),
+			[]object.Object{&object.Integer{Value: 1}},
+			"wrong number of arguments, got=1, want=2",
+		},
+		{
+			&object.Integer{Value: 1},
+			nil,
+			"not a function: INTEGER",
+		},
+		{
+			nil,
+			nil,
+			"not a function: nil",
+		},
+	}
+	for _, tt := range tests {
+		got, err := Call(tt.fn, tt.args...)
+		if err == nil {
+			t.Errorf("no error returned, got=%T (%+v)", got, got)
+			continue
+		}
+		if err.Error() != tt.wantMsg {
+			t.Errorf("wrong error message, want=%q, got=%q", tt.wantMsg, err.Error())
+		}
+	}
+}
@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rtfb/tarsier/ast"
 	"github.com/rtfb/tarsier/object"
@@ -37,7 +38,41 @@ func Eval(node ast.Node, env *object.Env) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Name == nil {
+			return evalUnpacking(node, val, env)
+		}
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+				fn.Name = node.Name.Value
+			}
+		}
+		if errObj := declare(env, node.Name.Value, val, node.IsConst()); errObj != nil {
+			return errObj
+		}
+	case *ast.FunctionStatement:
+		fn := Eval(node.Function, env)
+		if errObj := declare(env, node.Name.Value, fn, false); errObj != nil {
+			return errObj
+		}
+	case *ast.StructStatement:
+		fields := make([]string, len(node.Fields))
+		for i, f := range node.Fields {
+			fields[i] = f.Value
+		}
+		methods := make(map[string]object.Object, len(node.Methods))
+		for _, m := range node.Methods {
+			methods[m.Name] = Eval(m, env)
+		}
+		s := &object.Struct{
+			Name:    node.Name.Value,
+			Fields:  fields,
+			Methods: methods,
+		}
+		if errObj := declare(env, node.Name.Value, s, false); errObj != nil {
+			return errObj
+		}
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
 	// expressions:
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
@@ -46,6 +81,9 @@ func Eval(node ast.Node, env *object.Env) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if node.Operator == "??" {
+			return evalNullCoalescing(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -54,9 +92,14 @@ func Eval(node ast.Node, env *object.Env) object.Object {
 		if isError(right) {
 			return right
 		}
+		if result, ok := evalOverloadedInfixExpression(node.Operator, left, right, env); ok {
+			return result
+		}
 		return evalInfixExpression(node.Operator, left, right)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{
 			Value: node.Value,
@@ -67,29 +110,22 @@ func Eval(node ast.Node, env *object.Env) object.Object {
 		}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return Null
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
 		return &object.Function{
+			Name:       node.Name,
 			Parameters: params,
 			Body:       body,
 			Env:        env,
 		}
 	case *ast.CallExpression:
-		if node.Function.TokenLiteral() == "quote" {
-			return quote(node.Arguments[0], env)
-		}
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		return applyFunction(function, args)
+		result, _, _ := evalChain(node, env)
+		return result
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -99,34 +135,108 @@ func Eval(node ast.Node, env *object.Env) object.Object {
 			Elements: elements,
 		}
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
+		result, _, _ := evalChain(node, env)
+		return result
+	case *ast.TupleLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
 		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
+		return &object.Tuple{
+			Elements: elements,
 		}
-		return evalIndexExpression(left, index)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	}
 	return nil
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// evalChain evaluates a chain of index expressions and calls, like
+// `a?.b.c(1)`. Along with the result it returns the indexed object, which is
+// the receiver when the result is called as a method, and whether an optional
+// index short-circuited the chain. Once one does, the rest of the chain is
+// skipped and the whole chain evaluates to null. The receiver is nil if
+// evaluation stopped before indexing.
+func evalChain(node ast.Expression, env *object.Env) (result, receiver object.Object, skipped bool) {
+	switch node := node.(type) {
+	case *ast.IndexExpression:
+		left, _, skipped := evalChain(node.Left, env)
+		if skipped || isError(left) {
+			return left, nil, skipped
+		}
+		if node.Optional && isNull(left) {
+			return Null, nil, true
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index, nil, false
+		}
+		return evalIndexExpression(left, index), left, false
+	case *ast.CallExpression:
+		if node.Function.TokenLiteral() == "quote" {
+			return quote(node.Arguments[0], env), nil, false
+		}
+		function, receiver, skipped := evalChain(node.Function, env)
+		if skipped || isError(function) {
+			return function, nil, skipped
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0], nil, false
+		}
+		if _, ok := function.(*object.Function); ok && hasMethods(receiver) {
+			return applyMethod(function, receiver, args, env), nil, false
+		}
+		return applyFunction(function, args, env), nil, false
+	}
+	return Eval(node, env), nil, false
+}
+
+// applyFunction calls fn with args. The env is the one of the call site, it is
+// only passed on to builtins.
+func applyFunction(fn object.Object, args []object.Object, env *object.Env) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			if fn.Name != "" {
+				return newError("wrong number of arguments to `%s`, got=%d, want=%d",
+					fn.Name, len(args), len(fn.Parameters))
+			}
+			return newError("wrong number of arguments, got=%d, want=%d",
+				len(args), len(fn.Parameters))
+		}
 		extendedEnv := extendedFunctionEnv(fn, args)
-		evaled := Eval(fn.Body, extendedEnv)
+		// the body doesn't need a scope of its own, extendedEnv is one already:
+		evaled := evalStatements(fn.Body.Statements, extendedEnv)
 		return unwrapReturnValue(evaled)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return fn.Fn(newBuiltinContext(env), args...)
+	case *object.Struct:
+		if len(args) != len(fn.Fields) {
+			return newError("wrong number of arguments to `%s`, got=%d, want=%d",
+				fn.Name, len(args), len(fn.Fields))
+		}
+		values := make([]object.Object, len(args))
+		copy(values, args)
+		return &object.Record{
+			Struct: fn,
+			Values: values,
+		}
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
+func newBuiltinContext(env *object.Env) *object.BuiltinContext {
+	return &object.BuiltinContext{
+		Env: env,
+		Out: env.Out(),
+		Apply: func(fn object.Object, args ...object.Object) object.Object {
+			return applyFunction(fn, args, env)
+		},
+	}
+}
+
 func extendedFunctionEnv(fn *object.Function, args []object.Object) *object.Env {
 	env := object.NewEnclosedEnv(fn.Env)
 	for i, param := range fn.Parameters {
@@ -142,18 +252,45 @@ func unwrapReturnValue(o object.Object) object.Object {
 	return o
 }
 
+// evalExpressions evaluates a list of array elements or call arguments,
+// splicing the elements of spread arrays and tuples into the result.
 func evalExpressions(exps []ast.Expression, env *object.Env) []object.Object {
-	result := make([]object.Object, len(exps))
-	for i, e := range exps {
+	result := make([]object.Object, 0, len(exps))
+	for _, e := range exps {
+		spread, isSpread := e.(*ast.SpreadExpression)
+		if isSpread {
+			e = spread.Value
+		}
 		evaled := Eval(e, env)
 		if isError(evaled) {
 			return []object.Object{evaled}
 		}
-		result[i] = evaled
+		if !isSpread {
+			result = append(result, evaled)
+			continue
+		}
+		switch evaled := evaled.(type) {
+		case *object.Array:
+			result = append(result, evaled.Elements...)
+		case *object.Tuple:
+			result = append(result, evaled.Elements...)
+		default:
+			return []object.Object{newError("cannot spread %s", evaled.Type())}
+		}
 	}
 	return result
 }
 
+// evalNullCoalescing evaluates the right operand of ?? only if the left one is
+// null.
+func evalNullCoalescing(node *ast.InfixExpression, env *object.Env) object.Object {
+	left := Eval(node.Left, env)
+	if !isNull(left) {
+		return left
+	}
+	return Eval(node.Right, env)
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Env) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -194,9 +331,85 @@ func evalProgram(stmts []ast.Statement, env *object.Env) object.Object {
 	return result
 }
 
+// evalEnumStatement binds the enum to its name and each of its variants to
+// theirs, see object.Enum.Variant.
+func evalEnumStatement(es *ast.EnumStatement, env *object.Env) object.Object {
+	enum := &object.Enum{
+		Name: es.Name.Value,
+	}
+	for _, v := range es.Variants {
+		fields := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			fields[i] = f.Value
+		}
+		enum.Variants = append(enum.Variants, &object.Struct{
+			Name:   v.Name.Value,
+			Fields: fields,
+			Enum:   enum,
+		})
+	}
+	if errObj := declare(env, es.Name.Value, enum, false); errObj != nil {
+		return errObj
+	}
+	for _, v := range es.Variants {
+		variant, _ := enum.Variant(v.Name.Value)
+		if errObj := declare(env, v.Name.Value, variant, false); errObj != nil {
+			return errObj
+		}
+	}
+	return nil
+}
+
+// evalUnpacking binds the names of `let (a, b) = val;` to the elements of a
+// tuple or an array, skipping the ones named `_`.
+func evalUnpacking(ls *ast.LetStatement, val object.Object, env *object.Env) object.Object {
+	var elements []object.Object
+	switch val := val.(type) {
+	case *object.Tuple:
+		elements = val.Elements
+	case *object.Array:
+		elements = val.Elements
+	default:
+		return newError("cannot unpack %s", val.Type())
+	}
+	if len(elements) != len(ls.Names) {
+		return newError("cannot unpack %d values into %d names", len(elements), len(ls.Names))
+	}
+	for i, name := range ls.Names {
+		if name.Value == "_" {
+			continue
+		}
+		if errObj := declare(env, name.Value, elements[i], ls.IsConst()); errObj != nil {
+			return errObj
+		}
+	}
+	return nil
+}
+
+// evalBlockStatement evaluates a block in its own scope, so that the names it
+// declares don't leak to the enclosing one. Blocks without declarations don't
+// need that and reuse the enclosing env.
 func evalBlockStatement(block *ast.BlockStatement, env *object.Env) object.Object {
-	var result object.Object
+	if declaresNames(block) {
+		env = object.NewEnclosedEnv(env)
+	}
+	return evalStatements(block.Statements, env)
+}
+
+func declaresNames(block *ast.BlockStatement) bool {
 	for _, statement := range block.Statements {
+		switch statement.(type) {
+		case *ast.LetStatement, *ast.FunctionStatement, *ast.StructStatement,
+			*ast.EnumStatement:
+			return true
+		}
+	}
+	return false
+}
+
+func evalStatements(stmts []ast.Statement, env *object.Env) object.Object {
+	var result object.Object
+	for _, statement := range stmts {
 		result = Eval(statement, env)
 		if result != nil {
 			rt := result.Type()
@@ -221,21 +434,45 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "*" && left.Type() == object.ObjTypeString && right.Type() == object.ObjTypeInteger:
+		return evalStringRepetition(left.(*object.String), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.ObjTypeInteger && right.Type() == object.ObjTypeString:
+		return evalStringRepetition(right.(*object.String), left.(*object.Integer))
+	case operator == "*" && left.Type() == object.ObjTypeArray && right.Type() == object.ObjTypeInteger:
+		return evalArrayRepetition(left.(*object.Array), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.ObjTypeInteger && right.Type() == object.ObjTypeArray:
+		return evalArrayRepetition(right.(*object.Array), left.(*object.Integer))
+	case operator == "==":
+		return nativeBoolToBooleanObject(object.Equal(left, right))
+	case operator == "!=":
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	case operator == "in" && right.Type() == object.ObjTypeArray:
+		return nativeBoolToBooleanObject(indexOf(right.(*object.Array), left) >= 0)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	case left.Type() == object.ObjTypeInteger && right.Type() == object.ObjTypeInteger:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.ObjTypeString && right.Type() == object.ObjTypeString:
 		return evalStringInfixExpression(operator, left, right)
-	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
-	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+	case operator == "+" && left.Type() == object.ObjTypeArray:
+		return evalArrayConcatenation(left.(*object.Array), right.(*object.Array))
+	case operator == "+" && left.Type() == object.ObjTypeHash:
+		return evalHashMerge(left.(*object.Hash), right.(*object.Hash))
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// indexOf returns the index of the first element of arr equal to obj, or -1.
+func indexOf(arr *object.Array, obj object.Object) int {
+	for i, el := range arr.Elements {
+		if object.Equal(el, obj) {
+			return i
+		}
+	}
+	return -1
+}
+
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
@@ -252,24 +489,97 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
-	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
-	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "in":
+		return nativeBoolToBooleanObject(strings.Contains(rightVal, leftVal))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// maxRepetitionLen limits the length of a string or an array produced by a
+// repetition, so that a huge count reports an error instead of crashing.
+const maxRepetitionLen = 1 << 26
+
+// checkRepetition validates that repeating something of the given length
+// count times produces a result within maxRepetitionLen.
+func checkRepetition(length int, count int64) *object.Error {
+	if count < 0 {
+		return newError("negative repetition count: %d", count)
+	}
+	if length > 0 && count > int64(maxRepetitionLen/length) {
+		return newError("repetition result too large: %d * %d", length, count)
+	}
+	return nil
+}
+
+func evalStringRepetition(str *object.String, count *object.Integer) object.Object {
+	if err := checkRepetition(len(str.Value), count.Value); err != nil {
+		return err
+	}
 	return &object.String{
-		Value: leftVal + rightVal,
+		Value: strings.Repeat(str.Value, int(count.Value)),
+	}
+}
+
+func evalArrayRepetition(arr *object.Array, count *object.Integer) object.Object {
+	if err := checkRepetition(len(arr.Elements), count.Value); err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return &object.Array{Elements: []object.Object{}}
+	}
+	elements := make([]object.Object, 0, len(arr.Elements)*int(count.Value))
+	for i := int64(0); i < count.Value; i++ {
+		elements = append(elements, arr.Elements...)
+	}
+	return &object.Array{
+		Elements: elements,
+	}
+}
+
+func evalArrayConcatenation(left, right *object.Array) object.Object {
+	elements := make([]object.Object, 0, len(left.Elements)+len(right.Elements))
+	elements = append(elements, left.Elements...)
+	elements = append(elements, right.Elements...)
+	return &object.Array{
+		Elements: elements,
+	}
+}
+
+// evalHashMerge creates a new hash with the pairs of both operands. The right
+// one wins for keys present in both.
+func evalHashMerge(left, right *object.Hash) object.Object {
+	merged := &object.Hash{}
+	for _, pair := range left.Pairs() {
+		merged.Set(pair.Key, pair.Value)
 	}
+	for _, pair := range right.Pairs() {
+		merged.Set(pair.Key, pair.Value)
+	}
+	return merged
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
@@ -295,6 +605,20 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	}
 }
 
+// declare binds a name like Env.Declare, reporting errors as error objects.
+// The builtins behave as constants of the top-level env, so a program can't
+// rebind them there, e.g. to change how the stdlib functions using them work.
+// Nested envs can still shadow them.
+func declare(env *object.Env, name string, val object.Object, constant bool) *object.Error {
+	if _, ok := builtins[name]; ok && env.IsTopLevel() {
+		return newError("cannot redeclare constant %q", name)
+	}
+	if err := env.Declare(name, val, constant); err != nil {
+		return newError("%s", err)
+	}
+	return nil
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Env) object.Object {
 	if val, ok := env.Get(node.Value); ok {
 		return val
@@ -309,8 +633,14 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ObjTypeArray && index.Type() == object.ObjTypeInteger:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.ObjTypeTuple && index.Type() == object.ObjTypeInteger:
+		return evalTupleIndexExpression(left, index)
 	case left.Type() == object.ObjTypeHash:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.ObjTypeRecord:
+		return evalRecordFieldExpression(left, index)
+	case left.Type() == object.ObjTypeEnum:
+		return evalEnumVariantExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -326,43 +656,83 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+	tupleObject := tuple.(*object.Tuple)
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= int64(len(tupleObject.Elements)) {
+		return Null
+	}
+	return tupleObject.Elements[idx]
+}
+
+// evalRecordFieldExpression looks up a field of a record, or a method of its
+// struct. Unlike with hashes, a missing field is an error.
+func evalRecordFieldExpression(record, field object.Object) object.Object {
+	recordObject := record.(*object.Record)
+	name, ok := field.(*object.String)
+	if !ok {
+		return newError("record field must be STRING, got %s", field.Type())
+	}
+	if value, ok := recordObject.Get(name.Value); ok {
+		return value
+	}
+	if method, ok := recordObject.Struct.Methods[name.Value]; ok {
+		return method
+	}
+	return unknownFieldError(recordObject, name.Value)
+}
+
+func unknownFieldError(record *object.Record, field string) *object.Error {
+	return newError("unknown field %q in %s", field, record.Struct.Name)
+}
+
+// evalEnumVariantExpression looks up a variant of an enum, e.g. `Shape.Circle`.
+func evalEnumVariantExpression(enum, tag object.Object) object.Object {
+	enumObject := enum.(*object.Enum)
+	name, ok := tag.(*object.String)
+	if !ok {
+		return newError("enum variant must be STRING, got %s", tag.Type())
+	}
+	variant, ok := enumObject.Variant(name.Value)
+	if !ok {
+		return newError("unknown variant %q in %s", name.Value, enumObject.Name)
+	}
+	return variant
+}
+
+// evalHashIndexExpression looks the index up in the hash and its prototype
+// chain, see protoKey.
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := lookupHash(hashObject, key)
 	if !ok {
 		return Null
 	}
-	return pair.Value
+	return value
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Env) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	hash := &object.Hash{}
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{
-			Key:   key,
-			Value: value,
-		}
-	}
-	return &object.Hash{
-		Pairs: pairs,
+		hash.Set(hashKey, value)
 	}
+	return hash
 }
 
 func newError(format string, a ...interface{}) *object.Error {
@@ -371,6 +741,10 @@ func newError(format string, a ...interface{}) *object.Error {
 	}
 }
 
+func isNull(o object.Object) bool {
+	return o != nil && o.Type() == object.ObjTypeNull
+}
+
 func isError(o object.Object) bool {
 	if o != nil {
 		return o.Type() == object.ObjTypeError
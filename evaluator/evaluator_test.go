@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/rtfb/tarsier/lexer"
@@ -59,6 +60,37 @@ func TestEvalBoolExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" != "a"`, false},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"ab" < "a"`, false},
+		{`"a" <= "a"`, true},
+		{`"b" >= "c"`, false},
+		{`"ell" in "hello"`, true},
+		{`"" in "hello"`, true},
+		{`"hey" in "hello"`, false},
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, [2, 3]] != [1, [2, 3]]", false},
+		{`[1, "2"] == [1, 2]`, false},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{"1 == true", false},
+		{"1 != true", true},
+		{"if (false) { 1 } == if (false) { 2 }", true},
+		{"let f = fn() { 1 }; f == f", true},
+		{"fn() { 1 } == fn() { 1 }", false},
+		{"2 in [1, 2, 3]", true},
+		{"[2] in [1, [2], 3]", true},
+		{"4 in [1, 2, 3]", false},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(t, tt.input)
@@ -179,10 +211,30 @@ func TestErrorHandling(t *testing.T) {
 			`"Hello" - "World"`,
 			"unknown operator: STRING - STRING",
 		},
+		{
+			`"ab" * -1`,
+			"negative repetition count: -1",
+		},
+		{
+			`"ab" * 4611686018427387904`,
+			"repetition result too large: 2 * 4611686018427387904",
+		},
+		{
+			`"ab" - 1`,
+			"type mismatch: STRING - INTEGER",
+		},
+		{
+			`"a" in 1`,
+			"type mismatch: STRING in INTEGER",
+		},
 		{
 			`{"name": "Monkey"}[fn(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			`{[1, fn(x) { x }]: 1}`,
+			"unusable as hash key: ARRAY",
+		},
 	}
 	for _, tt := range tests {
 		evaled := testEval(t, tt.input)
@@ -212,6 +264,80 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"let x = 1; if (true) { let x = 2; x }", 2},
+		{"let x = 1; if (true) { let x = 2; x }; x", 1},
+		{"let x = 1; if (false) { 0 } else { let x = 3; x }; x", 1},
+		{"if (true) { let y = 2; y }; y", `identifier not found: "y"`},
+		{"let x = 1; if (true) { x + 1 }", 2},
+		{"let x = 1; if (true) { let y = x + 1; if (true) { let x = y * 10; x } }", 20},
+		{"let f = fn() { let x = 5; if (true) { let x = 6; x }; x }; f()", 5},
+		{`let f = if (true) { let n = 3; fn() { n } }; let n = 4; f()`, 3},
+		{`let x = 1; if (true) { let f = fn(n) { if (n == 0) { x } else { f(n - 1) } }; f(3) }`, 1},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch want := tt.want.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(want))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error, got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != want {
+				t.Errorf("wrong error message, want=%q, got=%q", want, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let b = a * 2; b;", 10},
+		{"let a = 1; const a = 2; a", 2},
+		{"const a = 1; if (true) { let a = 2; a }", 2},
+		{"const a = 1; if (true) { const a = 2; a }; a", 1},
+		{"const a = 1; fn(a) { a }(3)", 3},
+		{"const a = 1; let a = 2;", `cannot redeclare constant "a"`},
+		{"const a = 1; const a = 2;", `cannot redeclare constant "a"`},
+		{"if (true) { const a = 1; let a = 2; a }", `cannot redeclare constant "a"`},
+		{"const sum = fn(xs) { reduce(xs, 0, fn(acc, x) { acc + x }) }; let reduce = fn(a, b, c) { 99 }; sum([1, 2, 3])", `cannot redeclare constant "reduce"`},
+		{"let map = fn(f, xs) { xs };", `cannot redeclare constant "map"`},
+		{"fn len(x) { 0 }", `cannot redeclare constant "len"`},
+		{"struct first { x }", `cannot redeclare constant "first"`},
+		{"enum Opt { push(x), none }", `cannot redeclare constant "push"`},
+		{"let (len, b) = (1, 2);", `cannot redeclare constant "len"`},
+		{"if (true) { let len = 2; len }", 2},
+		{"fn(len) { len }(3)", 3},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch want := tt.want.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(want))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error, got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != want {
+				t.Errorf("wrong error message, want=%q, got=%q", want, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; }"
 	evaluated := testEval(t, input)
@@ -248,6 +374,377 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestNamedFunctions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"fn add(a, b) { a + b }; add(1, 2)", "3"},
+		{"fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)", "120"},
+		{"fn add(a, b) { a + b }; add", "fn add(a, b) {\n(a + b)\n}"},
+		{"let inc = fn(a) { a + 1 }; inc", "fn inc(a) {\n(a + 1)\n}"},
+		{"let inc = fn(a) { a + 1 }; let alias = inc; alias", "fn inc(a) {\n(a + 1)\n}"},
+		{"fn(a) { a }", "fn(a) {\na\n}"},
+		{"let f = fn named() { 1 }; f", "fn named() {\n1\n}"},
+		{"if (true) { fn local() { 1 } }; local", `ERROR: identifier not found: "local"`},
+		{"fn add(a, b) { a + b }; add(1)", "ERROR: wrong number of arguments to `add`, got=1, want=2"},
+		{"fn(a, b) { a + b }(1)", "ERROR: wrong number of arguments, got=1, want=2"},
+		{"const add = 1; fn add() { 2 }", `ERROR: cannot redeclare constant "add"`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let inc = n => n + 1; inc(1)", "2"},
+		{"((a, b) => a * b)(3, 4)", "12"},
+		{"(() => 7)()", "7"},
+		{"map([1, 2, 3], n => n * n)", "[1, 4, 9]"},
+		{"reduce([1, 2, 3], 0, (acc, n) => acc + n)", "6"},
+		{"let add = x => y => x + y; add(1)(2)", "3"},
+		{"let f = (x) => { let y = x + 1; y * 2 }; f(1)", "4"},
+		{"let inc = n => n + 1; inc", "fn inc(n) {\n(n + 1)\n}"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let inc = n => n + 1; 1 |> inc()", "2"},
+		{"let inc = n => n + 1; 1 |> inc |> inc", "3"},
+		{"[1, 2, 3] |> len()", "3"},
+		{"[1, 2, 3] |> push(4)", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4] |> filter(n => n > 2) |> map(n => n * 10)", "[30, 40]"},
+		{"[1, 2, 3] |> reduce(0, (acc, n) => acc + n) |> (n => n * 2)", "12"},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(3)", "7"},
+		{"let adder = fn(a) { fn(b) { a + b } }; 5 |> (adder(2))", "7"},
+		{"1 |> len()", "ERROR: argument to `len` must be STRING|ARRAY|TUPLE, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let xs = [2, 3]; [1, ...xs, 4]", "[1, 2, 3, 4]"},
+		{"[...[], ...[1], ...[]]", "[1]"},
+		{"let xs = [1, 2]; [...xs, ...xs]", "[1, 2, 1, 2]"},
+		{"let add = fn(a, b, c) { a + b + c }; add(...[1, 2, 3])", "6"},
+		{"let add = fn(a, b, c) { a + b + c }; add(1, ...[2, 3])", "6"},
+		{"let f = fn(a, b) { a }; f(...[1])", "ERROR: wrong number of arguments to `f`, got=1, want=2"},
+		{"puts(...[])", "null"},
+		{"len(...[[1, 2]])", "2"},
+		{"[1, ...2]", "ERROR: cannot spread INTEGER"},
+		{"let f = fn(a) { a }; f(...x)", `ERROR: identifier not found: "x"`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`match (1) { 1 => "one", 2 => "two" }`, "one"},
+		{`match (2) { 1 => "one", _ => "other" }`, "other"},
+		{`match ("b") { "a" => 1, "b" => 2 }`, "2"},
+		{`match (-3) { -3 => true, _ => false }`, "true"},
+		{`match (null) { null => "nothing", _ => "something" }`, "nothing"},
+		{`match (5) { n => n * 2 }`, "10"},
+		{`match (-5) { n if (n > 0) => "pos", n if (n < 0) => "neg", _ => "zero" }`, "neg"},
+		{`match ([]) { [] => "empty", [x] => "one", [x, ...xs] => "many" }`, "empty"},
+		{`match ([7]) { [] => "empty", [x] => x, [x, ...xs] => "many" }`, "7"},
+		{`match ([1, 2, 3]) { [x] => x, [x, ...xs] => xs }`, "[2, 3]"},
+		{`match ([1, 2]) { [1, x] => x, _ => 0 }`, "2"},
+		{`match ([1, 2]) { [2, x] => x, _ => 0 }`, "0"},
+		{`match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }`, "6"},
+		{`match ({"type": "circle", "r": 2}) { {"type": "square", "side": s} => s * s, {"type": "circle", "r": r} => 3 * r * r }`, "12"},
+		{`match ({"a": 1}) { {"b": b} => b, _ => "no b" }`, "no b"},
+		{`match (1) { [x] => x, {"a": a} => a, _ => "scalar" }`, "scalar"},
+		{`let x = 1; match (2) { x => x }; x`, "1"},
+		{`match (3) { x => { let y = x * 2; y + 1 } }`, "7"},
+		{`let f = fn(v) { match (v) { 0 => { return "zero"; }, _ => 1 }; "after" }; f(0)`, "zero"},
+		{`match (3) { 1 => "one", 2 => "two" }`, "ERROR: no match for 3"},
+		{`match ([1]) { [x] if (x > y) => x }`, `ERROR: identifier not found: "y"`},
+		{`match (z) { _ => 1 }`, `ERROR: identifier not found: "z"`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMemberAccess(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`let config = {"server": {"port": 8080}}; config.server.port`, "8080"},
+		{`let h = {"a": 1}; h.b`, "null"},
+		{`let h = {"a": 1}; h.a == h["a"]`, "true"},
+		{`let counter = {"inc": fn(n) { n + 1 }}; counter.inc(1)`, "2"},
+		{`let h = {"fns": [fn() { 42 }]}; h.fns[0]()`, "42"},
+		{`let h = null; h?.a`, "null"},
+		{`let h = {"a": null}; h.a?.b`, "null"},
+		{`let h = {"a": {"b": 2}}; h?.a?.b`, "2"},
+		{`let cfg = null; cfg?.server.port`, "null"},
+		{`let cfg = {"server": null}; cfg.server?.port.number`, "null"},
+		{`let h = null; h?.f(1).g`, "null"},
+		{`let h = null; h?.a.b ?? 7`, "7"},
+		{`let h = {"match": 1, "in": {"null": 2}}; [h.match, h.in.null, h?.if]`, "[1, 2, null]"},
+		{`let n = 1; n.a`, "ERROR: index operator not supported: INTEGER"},
+		{`let h = {"a": null}; h.a.b`, "ERROR: index operator not supported: NULL"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"struct Point { x, y } Point(1, 2)", "Point{x: 1, y: 2}"},
+		{"struct Point { x, y } Point", "struct Point { x, y }"},
+		{"struct Point { x, y } let p = Point(1, 2); p.x + p.y", "3"},
+		{`struct Point { x, y } Point(1, 2)["y"]`, "2"},
+		{"struct Point { x, y } let p = Point(1, 2); let q = set(p, \"x\", 5); [p, q]", "[Point{x: 1, y: 2}, Point{x: 5, y: 2}]"},
+		{"struct Point { x, y } Point(1, 2) == Point(1, 2)", "true"},
+		{"struct Point { x, y } Point(1, 2) != Point(2, 1)", "true"},
+		{"struct A { v } struct B { v } A(1) == B(1)", "false"},
+		{"struct Point { x, y } {Point(1, 2): \"a\"}[Point(1, 2)]", "a"},
+		{"struct Point { x, y } map([1, 2], n => Point(n, n))", "[Point{x: 1, y: 1}, Point{x: 2, y: 2}]"},
+		{"struct Box { v } map([1, 2], Box)", "[Box{v: 1}, Box{v: 2}]"},
+		{"struct Unit {} Unit()", "Unit{}"},
+		{"if (true) { struct Inner { v } }; Inner", `ERROR: identifier not found: "Inner"`},
+		{"struct Point { x, y } Point(1)", "ERROR: wrong number of arguments to `Point`, got=1, want=2"},
+		{"struct Point { x, y } Point(1, 2).z", `ERROR: unknown field "z" in Point`},
+		{"struct Point { x, y } Point(1, 2)[0]", "ERROR: record field must be STRING, got INTEGER"},
+		{"struct Point { x, y } set(Point(1, 2), \"z\", 1)", `ERROR: unknown field "z" in Point`},
+		{"set({}, \"z\", 1)", "ERROR: argument to `set` must be RECORD, got HASH"},
+		{"struct Point { x, y } {Point(1, fn() {}): 1}", "ERROR: unusable as hash key: RECORD"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	shape := "enum Shape { Circle(r), Rect(w, h), Empty } "
+	area := shape + `let area = fn(s) {
+	match (s) {
+		Circle(r) => 3 * r * r,
+		Rect(w, h) => w * h,
+		Empty => 0,
+	}
+}; `
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{shape + "Circle(2)", "Circle{r: 2}"},
+		{shape + "Empty", "Empty"},
+		{shape + "Shape", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{shape + "Rect", "Shape.Rect(w, h)"},
+		{shape + "Shape.Rect(1, 2)", "Rect{w: 1, h: 2}"},
+		{shape + "Shape.Empty == Empty", "true"},
+		{shape + "Rect(1, 2).h", "2"},
+		{shape + "Circle(1) == Circle(1)", "true"},
+		{shape + "Circle(1) == Rect(1, 1)", "false"},
+		{shape + "[type(Circle(1)), type(Empty)]", "[Shape, Shape]"},
+		{area + "map([Circle(2), Rect(2, 3), Empty], area)", "[12, 6, 0]"},
+		{shape + "match (Circle(1)) { Empty => 0, _ => 1 }", "1"},
+		{shape + "match (Empty) { Empty() => 0, _ => 1 }", "0"},
+		{shape + "match (Rect(1, 2)) { Rect(1, h) => h, _ => 0 }", "2"},
+		{shape + "match (Rect(1, 2)) { Rect(2, h) => h, _ => 0 }", "0"},
+		{shape + "match (Rect(Circle(1), 2)) { Rect(Circle(r), _) => r }", "1"},
+		{"enum Option { Some(v), None } let get = fn(o, d) { match (o) { Some(v) => v, None => d } }; [get(Some(1), 0), get(None, 0)]", "[1, 0]"},
+		{"struct Point { x, y } match (Point(1, 2)) { Point(x, y) => x + y }", "3"},
+		{"struct A { v } struct B { v } match (B(1)) { A(v) => \"a\", B(v) => \"b\" }", "b"},
+		{`[type(1), type("a"), type([]), type({}), type(null), type(len)]`, "[INTEGER, STRING, ARRAY, HASH, NULL, BUILTIN]"},
+		{"struct Point { x, y } [type(Point(1, 2)), type(Point)]", "[Point, STRUCT]"},
+		{shape + "Circle()", "ERROR: wrong number of arguments to `Circle`, got=0, want=1"},
+		{shape + "Shape.Square", `ERROR: unknown variant "Square" in Shape`},
+		{shape + "match (Circle(1)) { Circle(a, b) => a }", "ERROR: wrong number of fields in pattern for `Circle`, got=2, want=1"},
+		{shape + "match (Circle(1)) { Square(a) => a }", `ERROR: identifier not found: "Square"`},
+		{shape + "let f = 1; match (Circle(1)) { f(a) => a }", "ERROR: not a struct or an enum variant in pattern: INTEGER"},
+		{shape + "match (Empty) { Circle(r) => r }", "ERROR: no match for Empty"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	vec := `struct Vec {
+	x, y,
+	fn __add__(o) { Vec(self.x + o.x, self.y + o.y) },
+	fn __sub__(o) { Vec(self.x - o.x, self.y - o.y) },
+	fn __mul__(k) { Vec(self.x * k, self.y * k) },
+	fn __div__(k) { Vec(self.x / k, self.y / k) },
+	fn __lt__(o) { self.x * self.x + self.y * self.y < o.x * o.x + o.y * o.y },
+} `
+	money := `let money = fn(cents) {
+	{"cents": cents, "__eq__": fn(o) { self["cents"] == o["cents"] }, "__add__": fn(o) { money(self["cents"] + o["cents"]) }}
+}; `
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{vec + "Vec(1, 2) + Vec(3, 4)", "Vec{x: 4, y: 6}"},
+		{vec + "Vec(3, 4) - Vec(1, 1)", "Vec{x: 2, y: 3}"},
+		{vec + "Vec(1, 2) * 3", "Vec{x: 3, y: 6}"},
+		{vec + "Vec(4, 2) / 2", "Vec{x: 2, y: 1}"},
+		{vec + "reduce([Vec(1, 1), Vec(2, 2)], Vec(0, 0), (a, b) => a + b)", "Vec{x: 3, y: 3}"},
+		{vec + "[Vec(1, 1) < Vec(2, 2), Vec(1, 1) > Vec(2, 2), Vec(1, 1) <= Vec(1, 1), Vec(1, 1) >= Vec(2, 2)]", "[true, false, true, false]"},
+		{vec + "Vec(1, 2) == Vec(1, 2)", "true"},
+		{vec + "3 * Vec(1, 2)", "ERROR: type mismatch: INTEGER * RECORD"},
+		{vec + "Vec(1, 2) + 1", "ERROR: index operator not supported: INTEGER"},
+		{money + `money(100) == {"cents": 100}`, "true"},
+		{money + `{"cents": 100} == money(100)`, "true"},
+		{money + `money(100) != money(200)`, "true"},
+		{money + `(money(100) + money(50))["cents"]`, "150"},
+		{`struct Id { v, fn __eq__(o) { true } } [Id(1) == Id(2), Id(1) != 5]`, "[true, false]"},
+		{`let h = {"__add__": 1}; h + {"a": 2}`, "{__add__: 1, a: 2}"},
+		{`let h = {"__lt__": fn(a, b) { true }}; h < h`, "ERROR: wrong number of arguments, got=1, want=2"},
+		{`struct S { fn __add__(o) { self } } let s = S(); s + 1 == s`, "true"},
+		{`let h = {"__add__": len}; h + "abc"`, "3"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPrototypes(t *testing.T) {
+	animal := `let Animal = {
+	"init": fn(name) { self + {"name": name} },
+	"speak": fn() { self.name + " makes a sound" },
+	"rename": fn(name) { self + {"name": name} },
+};
+let Dog = {"__proto__": Animal, "speak": fn() { self.name + " barks" }};
+`
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{animal + `new(Animal, "Cat").speak()`, "Cat makes a sound"},
+		{animal + `new(Dog, "Rex").speak()`, "Rex barks"},
+		{animal + `new(Dog, "Rex").rename("Max").speak()`, "Max barks"},
+		{animal + `let rex = new(Dog, "Rex"); rex.name`, "Rex"},
+		{animal + `let rex = new(Dog, "Rex"); rex["speak"]()`, "Rex barks"},
+		{animal + `let rex = new(Dog, "Rex"); rex.init("Max").speak()`, "Max barks"},
+		{animal + `let rex = new(Dog, "Rex"); rex.missing`, "null"},
+		{animal + `let rex = new(Dog, "Rex"); let speak = rex.speak; speak()`, `ERROR: identifier not found: "self"`},
+		{`let P = {"x": 1}; new(P).x`, "1"},
+		{`let P = {"x": 1}; new(P, 2)`, "ERROR: no init method to pass the arguments to"},
+		{`let P = {"init": fn(a, b) { self }}; new(P, 1)`, "ERROR: wrong number of arguments, got=1, want=2"},
+		{`new(1)`, "ERROR: argument to `new` must be HASH, got INTEGER"},
+		{`let P = {"__add__": fn(o) { self.v + o.v }}; new(P) + {"v": 1}`, "ERROR: type mismatch: NULL + INTEGER"},
+		{`let Num = {"init": fn(v) { {"__proto__": Num, "v": v} }, "__add__": fn(o) { new(Num, self.v + o.v) }}; (new(Num, 1) + new(Num, 2)).v`, "3"},
+		{`let counter = {"n": 1, "inc": fn() { self + {"n": self.n + 1} }}; counter.inc().inc().n`, "3"},
+		{`let h = {"f": fn() { 42 }}; h.f()`, "42"},
+		{`let h = {"__proto__": 5}; h.x`, "null"},
+		{animal + `match (new(Dog, "Rex")) { {"speak": f, "name": n} => n, _ => "none" }`, "Rex"},
+		{`let P = {"x": 1}; let h = new(P); [h.x, h["x"], h == {"__proto__": P}]`, "[1, 1, true]"},
+		{`let ns = {"size": len}; ns.size([1, 2])`, "2"},
+		{`struct Vec { x, y, fn dot(o) { self.x * o.x + self.y * o.y } } Vec(1, 2).dot(Vec(3, 4))`, "11"},
+		{`struct Vec { x, fn scaled(k) { set(self, "x", self.x * k) } } Vec(2).scaled(3).scaled(2)`, "Vec{x: 12}"},
+		{`struct Vec { x, fn get() { self.x } } let v = Vec(1); [v.get, v.get()]`, "[fn get() {\n(self[x])\n}, 1]"},
+		{`[1, 2].len()`, "ERROR: index operator not supported: ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"(1, 2 + 3)", "(1, 5)"},
+		{"(1,)", "(1,)"},
+		{"(1, (2, 3))[1]", "(2, 3)"},
+		{"(1, 2)[2]", "null"},
+		{"len((1, 2, 3))", "3"},
+		{"(1, 2) == (1, 2)", "true"},
+		{"(1, 2) == [1, 2]", "false"},
+		{"(1, [2]) != (1, [3])", "true"},
+		{`{(1, 2): "a", (2, 1): "b"}[(2, 1)]`, "b"},
+		{"divmod(7, 2)", "(3, 1)"},
+		{"divmod(-7, 2)", "(-3, -1)"},
+		{"let (q, r) = divmod(7, 2); q * 10 + r", "31"},
+		{"let (a, _, c) = (1, 2, 3); a + c", "4"},
+		{"let (_, b, _) = (1, 2, 3); b", "2"},
+		{"let (a, b) = [1, 2]; a + b", "3"},
+		{"let swap = fn(t) { let (a, b) = t; (b, a) }; swap((1, 2))", "(2, 1)"},
+		{"let add = fn(a, b) { a + b }; add(...(1, 2))", "3"},
+		{"[...(1, 2), 3]", "[1, 2, 3]"},
+		{"match (divmod(9, 3)) { (q, 0) => q, (_, r) => -r }", "3"},
+		{"match ((1,)) { (x, y) => 2, (x,) => 1 }", "1"},
+		{"match ([1, 2]) { (x, y) => 2, _ => 0 }", "0"},
+		{"const (a, b) = (1, 2); let a = 3;", `ERROR: cannot redeclare constant "a"`},
+		{"let (a, b) = (1, 2, 3);", "ERROR: cannot unpack 3 values into 2 names"},
+		{"let (a, b) = 1;", "ERROR: cannot unpack INTEGER"},
+		{"divmod(1, 0)", "ERROR: division by zero"},
+		{`divmod(1, "a")`, "ERROR: argument to `divmod` must be INTEGER, got STRING"},
+		{"{(1, fn() {}): 1}", "ERROR: unusable as hash key: TUPLE"},
+		{"type((1, 2))", "TUPLE"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 let newAdder = fn(x) {
@@ -283,6 +780,30 @@ func TestStringConcat(t *testing.T) {
 	}
 }
 
+func TestStringRepetition(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`"ab" * 3`, "ababab"},
+		{`3 * "ab"`, "ababab"},
+		{`"ab" * 0`, ""},
+		{`"-" * 2 + "+"`, "--+"},
+		{`"" * 4611686018427387904`, ""},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String, got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.want {
+			t.Errorf("String has wrong value, want=%q, got=%q", tt.want, str.Value)
+		}
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input string
@@ -291,8 +812,10 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len(1)`, "argument to `len` must be STRING|ARRAY|TUPLE, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments, got=2, want=1"},
+		{`index_of([1, [2], 3], [2])`, 1},
+		{`index_of([1, 2, 3], 4)`, -1},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(t, tt.input)
@@ -311,6 +834,71 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestCallbackBuiltins(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fn(x) { x * 2 })", "[]"},
+		{"map([1, 2], len)", "argument to `len` must be STRING|ARRAY|TUPLE, got INTEGER"},
+		{"filter([1, 2, 3, 4], fn(x) { x > 2 })", "[3, 4]"},
+		{"reduce([1, 2, 3], 10, fn(acc, x) { acc + x })", "16"},
+		{"reduce([], 10, fn(acc, x) { acc + x })", "10"},
+		{`sort_by([3, 1, 2], fn(x) { -x })`, "[3, 2, 1]"},
+		{`sort_by(["bb", "a", "ccc"], fn(x) { x })`, "[a, bb, ccc]"},
+		{`sort_by([[2, "b"], [1, "a"], [2, "a"]], first)`, "[[1, a], [2, b], [2, a]]"},
+		{`sort_by([1, "a"], fn(x) { x })`, "sort keys not comparable: STRING and INTEGER"},
+		{"each([1, 2], fn(x) { x })", "null"},
+		{"each([1, 2], fn(x) { x + true })", "type mismatch: INTEGER + BOOLEAN"},
+		{"map(1, fn(x) { x })", "argument to `map` must be ARRAY, got INTEGER"},
+		{"filter([1], 1)", "argument to `filter` must be FUNCTION|BUILTIN|STRUCT, got INTEGER"},
+		{"reduce([1], fn(x) { x })", "wrong number of arguments, got=2, want=3"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			if errObj.Message != tt.want {
+				t.Errorf("wrong error message, want=%q, got=%q", tt.want, errObj.Message)
+			}
+			continue
+		}
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPutsWritesToEnvOut(t *testing.T) {
+	program := testParseProgram(`puts("hello", 42)`)
+	var out bytes.Buffer
+	env := object.NewEnv()
+	env.SetOut(&out)
+	inner := object.NewEnclosedEnv(env)
+	Eval(program, inner)
+	if out.String() != "hello\n42\n" {
+		t.Errorf("wrong output, got=%q", out.String())
+	}
+}
+
+func TestPutsUsesStrMethod(t *testing.T) {
+	program := testParseProgram(`
+struct Money { amount, fn __str__() { self.amount + " EUR" } }
+let h = {"__str__": fn() { "a hash" }};
+puts(Money("2.50"), h, "plain", {"__str__": 1});`)
+	var out bytes.Buffer
+	env := object.NewEnv()
+	env.SetOut(&out)
+	Eval(program, env)
+	if out.String() != "2.50 EUR\na hash\nplain\n{__str__: 1}\n" {
+		t.Errorf("wrong output, got=%q", out.String())
+	}
+	errProgram := testParseProgram(`struct S { fn __str__() { 1 } } puts(S())`)
+	if got := Eval(errProgram, env).Inspect(); got != "ERROR: __str__ must return STRING, got INTEGER" {
+		t.Errorf("wrong error, got=%q", got)
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 	evaluated := testEval(t, input)
@@ -326,6 +914,86 @@ func TestArrayLiterals(t *testing.T) {
 	testIntegerObject(t, result.Elements[2], 6)
 }
 
+func TestPushRestSharing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let a = push([1], 2); let b = push(a, 3); let c = push(a, 4); [a, b, c]",
+			"[[1, 2], [1, 2, 3], [1, 2, 4]]"},
+		{"let a = push(push([], 1), 2); let r = rest(a); [push(r, 3), push(a, 4), r]",
+			"[[2, 3], [1, 2, 4], [2]]"},
+		{"rest([])", "null"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestNullHandling(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"null", "null"},
+		{"is_null(null)", "true"},
+		{"is_null(0)", "false"},
+		{"is_null([1][5])", "true"},
+		{"null == null", "true"},
+		{"null ?? 5", "5"},
+		{"false ?? 5", "false"},
+		{"1 ?? foo", "1"},
+		{"null ?? null ?? 3", "3"},
+		{"let h = null; h?.[0]", "null"},
+		{"let h = null; h?.[0] ?? 7", "7"},
+		{`let h = {"a": {"b": 1}}; h?.["a"]?.["b"]`, "1"},
+		{`let h = {"a": null}; h["a"]?.["b"]`, "null"},
+		{"null?.[foo]", "null"},
+		{"null?.[1][2]", "null"},
+		{"null?.[1][foo]", "null"},
+		{"null ?? foo", `ERROR: identifier not found: "foo"`},
+		{"null[0]", "ERROR: index operator not supported: NULL"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCollectionOperators(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[1, 2] + [3]", "[1, 2, 3]"},
+		{"[] + []", "[]"},
+		{"[1, 2] * 2", "[1, 2, 1, 2]"},
+		{"2 * [0]", "[0, 0]"},
+		{"[1] * 0", "[]"},
+		{"let a = [1]; let b = a + [2]; a", "[1]"},
+		{`{"a": 1} + {"b": 2} == {"a": 1, "b": 2}`, "true"},
+		{`{"a": 1, "b": 2} + {"b": 3} == {"a": 1, "b": 3}`, "true"},
+		{`let h = {"a": 1}; let m = h + {"a": 2}; h["a"]`, "1"},
+		{"[] * 4611686018427387904", "[]"},
+		{"[1] * -1", "ERROR: negative repetition count: -1"},
+		{"[1, 2] * 4611686018427387904", "ERROR: repetition result too large: 2 * 4611686018427387904"},
+		{"[1] - [1]", "ERROR: unknown operator: ARRAY - ARRAY"},
+		{`{} * {}`, "ERROR: unknown operator: HASH * HASH"},
+		{"[1] + 1", "ERROR: type mismatch: ARRAY + INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
+
 func TestArrayIndexExpressions(t *testing.T) {
 	tests := []struct {
 		input string
@@ -398,24 +1066,49 @@ func TestHashLiterals(t *testing.T) {
 	if !ok {
 		t.Fatalf("Eval didn't return Hash, got=%T (%+v)", evaluated, evaluated)
 	}
-	want := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		True.HashKey():                             5,
-		False.HashKey():                            6,
+	want := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{True, 5},
+		{False, 6},
 	}
-	if len(result.Pairs) != len(want) {
-		t.Fatalf("Hash has wrong num or pairs, got=%d, want=%d", len(result.Pairs),
+	if result.Len() != len(want) {
+		t.Fatalf("Hash has wrong num or pairs, got=%d, want=%d", result.Len(),
 			len(want))
 	}
-	for wantKey, wantValue := range want {
-		pair, ok := result.Pairs[wantKey]
+	for i, pair := range result.Pairs() {
+		if !object.Equal(pair.Key, want[i].key) {
+			t.Errorf("pair %d has wrong key, want=%s, got=%s", i,
+				want[i].key.Inspect(), pair.Key.Inspect())
+		}
+		value, ok := result.Get(want[i].key)
 		if !ok {
-			t.Errorf("no pair for key %q in Pairs", wantKey)
+			t.Errorf("no pair for key %q in Pairs", want[i].key.Inspect())
+		}
+		testIntegerObject(t, value, want[i].value)
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, "{z: 1, a: 2, m: 3}"},
+		{`{3: 1, 1: 2, 2: 3}`, "{3: 1, 1: 2, 2: 3}"},
+		{`{"b": 1, "a": 2, "b": 3}`, "{b: 3, a: 2}"},
+		{`{"b": 1, "a": 2} + {"c": 3, "b": 4}`, "{b: 4, a: 2, c: 3}"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.want, evaluated.Inspect())
 		}
-		testIntegerObject(t, pair.Value, wantValue)
 	}
 }
 
@@ -452,6 +1145,22 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{[1, 2]: 5}[[1, 2]]`,
+			5,
+		},
+		{
+			`{[1, 2]: 5}[[2, 1]]`,
+			nil,
+		},
+		{
+			`let grid = {[0, 0]: 1, [0, 1]: 5}; let x = 0; grid[[x, x + 1]]`,
+			5,
+		},
+		{
+			`{{"a": 1, "b": 2}: 5}[{"b": 2, "a": 1}]`,
+			5,
+		},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(t, tt.input)
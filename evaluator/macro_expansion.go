@@ -6,13 +6,15 @@ import (
 )
 
 // DefineMacros extracts macro definitions from a given AST, and stores them in
-// an environment.
-func DefineMacros(program *ast.Program, env *object.Env) {
+// an environment. It fails if a definition rebinds a constant macro.
+func DefineMacros(program *ast.Program, env *object.Env) error {
 	definitions := []int{}
 	for i, statement := range program.Statements {
 		if isMacroDefinition(statement) {
 			// NB: storing in the env happens inside this helper method:
-			addMacro(statement, env)
+			if err := addMacro(statement, env); err != nil {
+				return err
+			}
 			definitions = append(definitions, i)
 		}
 	}
@@ -23,18 +25,19 @@ func DefineMacros(program *ast.Program, env *object.Env) {
 			program.Statements[definitionIndex+1:]...,
 		)
 	}
+	return nil
 }
 
 func isMacroDefinition(node ast.Statement) bool {
 	letStatement, ok := node.(*ast.LetStatement)
-	if !ok {
+	if !ok || letStatement.Name == nil {
 		return false
 	}
 	_, ok = letStatement.Value.(*ast.MacroLiteral)
 	return ok
 }
 
-func addMacro(stmt ast.Statement, env *object.Env) {
+func addMacro(stmt ast.Statement, env *object.Env) error {
 	letStmt, _ := stmt.(*ast.LetStatement)
 	macroLiteral, _ := letStmt.Value.(*ast.MacroLiteral)
 	macro := object.Macro{
@@ -42,7 +45,7 @@ func addMacro(stmt ast.Statement, env *object.Env) {
 		Body:       macroLiteral.Body,
 		Env:        env,
 	}
-	env.Set(letStmt.Name.Value, &macro)
+	return env.Declare(letStmt.Name.Value, &macro, letStmt.IsConst())
 }
 
 // ExpandMacros takes an AST and an environment and expands macros found in the
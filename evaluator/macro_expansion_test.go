@@ -17,7 +17,9 @@ func TestDefineMacros(t *testing.T) {
 	`
 	env := object.NewEnv()
 	program := testParseProgram(input)
-	DefineMacros(program, env)
+	if err := DefineMacros(program, env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	if len(program.Statements) != 2 {
 		t.Fatalf("wrong number of statements, want=2, got=%d", len(program.Statements))
 	}
@@ -84,7 +86,9 @@ func TestExpandMacros(t *testing.T) {
 		want := testParseProgram(tt.want)
 		program := testParseProgram(tt.input)
 		env := object.NewEnv()
-		DefineMacros(program, env)
+		if err := DefineMacros(program, env); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		expanded := ExpandMacros(program, env)
 		if expanded.String() != want.String() {
 			t.Errorf("not equal: want=%q, got=%q", want.String(), expanded.String())
@@ -92,6 +96,24 @@ func TestExpandMacros(t *testing.T) {
 	}
 }
 
+func TestDefineConstMacros(t *testing.T) {
+	env := object.NewEnv()
+	stdlib := testParseProgram("const unless = macro(c, a, b) { quote(unquote(b)) };")
+	if err := DefineMacros(stdlib, env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	program := testParseProgram("let unless = macro(c, a, b) { quote(99) }; unless(true, 1, 2);")
+	err := DefineMacros(program, env)
+	wantErr := `cannot redeclare constant "unless"`
+	if err == nil || err.Error() != wantErr {
+		t.Fatalf("want error %q, got=%v", wantErr, err)
+	}
+	expanded := ExpandMacros(testParseProgram("unless(true, 1, 2);"), env)
+	if expanded.String() != "2" {
+		t.Errorf("const macro was replaced, want=%q, got=%q", "2", expanded.String())
+	}
+}
+
 func testParseProgram(input string) *ast.Program {
 	l := lexer.New(input)
 	p := parser.New(l)
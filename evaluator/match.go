@@ -0,0 +1,165 @@
+package evaluator
+
+import (
+	"github.com/rtfb/tarsier/ast"
+	"github.com/rtfb/tarsier/object"
+)
+
+// evalMatchExpression evaluates the body of the first arm whose pattern
+// matches the value and whose guard, if any, is truthy. Every arm gets a fresh
+// env for the names its pattern binds.
+func evalMatchExpression(me *ast.MatchExpression, env *object.Env) object.Object {
+	value := Eval(me.Value, env)
+	if isError(value) {
+		return value
+	}
+	for _, arm := range me.Arms {
+		armEnv := object.NewEnclosedEnv(env)
+		matched, errObj := matchPattern(arm.Pattern, value, armEnv)
+		if errObj != nil {
+			return errObj
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return evalStatements(arm.Body.Statements, armEnv)
+	}
+	return newError("no match for %s", value.Inspect())
+}
+
+// matchPattern reports whether value matches pattern, binding the names in the
+// pattern in env. On a failed match env can be left with some of the names
+// bound. Only constructor patterns referring to something that's not a
+// constructor are errors.
+func matchPattern(pattern ast.Expression, value object.Object, env *object.Env) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value == "_" {
+			return true, nil
+		}
+		if bound, ok := env.Get(pattern.Value); ok && isUnitVariant(bound) {
+			return object.Equal(bound, value), nil
+		}
+		env.Set(pattern.Value, value)
+		return true, nil
+	case *ast.CallExpression:
+		return matchConstructorPattern(pattern, value, env)
+	case *ast.ArrayLiteral:
+		return matchArrayPattern(pattern, value, env)
+	case *ast.TupleLiteral:
+		return matchTuplePattern(pattern, value, env)
+	case *ast.HashLiteral:
+		return matchHashPattern(pattern, value, env)
+	default:
+		// a literal, possibly a negated integer:
+		return object.Equal(Eval(pattern, env), value), nil
+	}
+}
+
+func isUnitVariant(obj object.Object) bool {
+	record, ok := obj.(*object.Record)
+	return ok && record.Struct.Enum != nil && len(record.Values) == 0
+}
+
+func matchConstructorPattern(pattern *ast.CallExpression, value object.Object, env *object.Env) (bool, *object.Error) {
+	name := pattern.Function.(*ast.Identifier).Value
+	constructor, ok := env.Get(name)
+	if !ok {
+		return false, newError("identifier not found: %q", name)
+	}
+	if isUnitVariant(constructor) && len(pattern.Arguments) == 0 {
+		return object.Equal(constructor, value), nil
+	}
+	s, ok := constructor.(*object.Struct)
+	if !ok {
+		return false, newError("not a struct or an enum variant in pattern: %s", constructor.Type())
+	}
+	if len(pattern.Arguments) != len(s.Fields) {
+		return false, newError("wrong number of fields in pattern for `%s`, got=%d, want=%d",
+			s.Name, len(pattern.Arguments), len(s.Fields))
+	}
+	record, ok := value.(*object.Record)
+	if !ok || record.Struct != s {
+		return false, nil
+	}
+	for i, arg := range pattern.Arguments {
+		if matched, errObj := matchPattern(arg, record.Values[i], env); !matched || errObj != nil {
+			return false, errObj
+		}
+	}
+	return true, nil
+}
+
+func matchTuplePattern(pattern *ast.TupleLiteral, value object.Object, env *object.Env) (bool, *object.Error) {
+	tuple, ok := value.(*object.Tuple)
+	if !ok || len(tuple.Elements) != len(pattern.Elements) {
+		return false, nil
+	}
+	for i, el := range pattern.Elements {
+		if matched, errObj := matchPattern(el, tuple.Elements[i], env); !matched || errObj != nil {
+			return false, errObj
+		}
+	}
+	return true, nil
+}
+
+func matchArrayPattern(pattern *ast.ArrayLiteral, value object.Object, env *object.Env) (bool, *object.Error) {
+	arr, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+	elements := pattern.Elements
+	var rest *ast.SpreadExpression
+	if n := len(elements); n > 0 {
+		if rest, ok = elements[n-1].(*ast.SpreadExpression); ok {
+			elements = elements[:n-1]
+		}
+	}
+	if len(arr.Elements) < len(elements) || rest == nil && len(arr.Elements) > len(elements) {
+		return false, nil
+	}
+	for i, el := range elements {
+		if matched, errObj := matchPattern(el, arr.Elements[i], env); !matched || errObj != nil {
+			return false, errObj
+		}
+	}
+	if rest != nil {
+		remaining := make([]object.Object, len(arr.Elements)-len(elements))
+		copy(remaining, arr.Elements[len(elements):])
+		return matchPattern(rest.Value, &object.Array{Elements: remaining}, env)
+	}
+	return true, nil
+}
+
+// matchHashPattern matches hashes that have all the keys of the pattern, also
+// in their prototype chain, with values matching the corresponding patterns.
+// Other keys are ignored.
+func matchHashPattern(pattern *ast.HashLiteral, value object.Object, env *object.Env) (bool, *object.Error) {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return false, nil
+	}
+	for _, pair := range pattern.Pairs {
+		key, ok := object.AsHashable(Eval(pair.Key, env))
+		if !ok {
+			return false, nil
+		}
+		val, ok := lookupHash(hash, key)
+		if !ok {
+			return false, nil
+		}
+		if matched, errObj := matchPattern(pair.Value, val, env); !matched || errObj != nil {
+			return false, errObj
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,151 @@
+package evaluator
+
+import (
+	"github.com/rtfb/tarsier/object"
+)
+
+// arithmeticMethods maps the arithmetic operators to the methods overloading
+// them.
+var arithmeticMethods = map[string]string{
+	"+": "__add__",
+	"-": "__sub__",
+	"*": "__mul__",
+	"/": "__div__",
+}
+
+// protoKey is the key of the prototype of a hash. It is reserved in every
+// hash: when it maps to another hash, looking up a key missing in the hash
+// continues in its prototype, and so on up the chain. This applies to all key
+// lookups, i.e. indexing, member access and hash patterns, while the hash
+// itself (its length, pairs and equality) only holds its own keys.
+var protoKey = &object.String{Value: "__proto__"}
+
+// lookupHash gets the value of a key in a hash or in its prototype chain. All
+// key lookups in hashes go through it, see protoKey.
+func lookupHash(hash *object.Hash, key object.Hashable) (object.Object, bool) {
+	for {
+		if value, ok := hash.Get(key); ok {
+			return value, true
+		}
+		proto, ok := hash.Get(protoKey)
+		if !ok {
+			return nil, false
+		}
+		if hash, ok = proto.(*object.Hash); !ok {
+			return nil, false
+		}
+	}
+}
+
+// hasMethods reports whether obj can have methods, so user functions got from
+// it by indexing or member access are called with self bound to it. Builtins,
+// like the ones in a builtin namespace, are called without self.
+func hasMethods(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Record, *object.Hash:
+		return true
+	}
+	return false
+}
+
+// findMethod looks up a method of a record (declared in its struct) or of a
+// hash (a callable stored under the method name, possibly in its prototype
+// chain). Other objects don't have methods.
+func findMethod(obj object.Object, name string) (object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Record:
+		method, ok := obj.Struct.Methods[name]
+		return method, ok
+	case *object.Hash:
+		method, ok := lookupHash(obj, &object.String{Value: name})
+		if !ok {
+			return nil, false
+		}
+		switch method.(type) {
+		case *object.Function, *object.Builtin:
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// applyMethod calls a method with `self` bound to the receiver. Builtins
+// can't see self, they only get args.
+func applyMethod(method, self object.Object, args []object.Object, env *object.Env) object.Object {
+	if fn, ok := method.(*object.Function); ok {
+		bound := *fn
+		bound.Env = object.NewEnclosedEnv(fn.Env)
+		bound.Env.Set("self", self)
+		method = &bound
+	}
+	return applyFunction(method, args, env)
+}
+
+// evalOverloadedInfixExpression dispatches an operator to a method of its
+// operands, reporting false if they don't overload it. Arithmetic dispatches
+// on the left operand. `==` and `!=` use __eq__ of either operand, the left
+// one first. All the comparisons are derived from __lt__: `a > b` is
+// `b < a`, `a <= b` is `!(b < a)` and `a >= b` is `!(a < b)`.
+func evalOverloadedInfixExpression(operator string, left, right object.Object, env *object.Env) (object.Object, bool) {
+	if name, ok := arithmeticMethods[operator]; ok {
+		method, ok := findMethod(left, name)
+		if !ok {
+			return nil, false
+		}
+		return applyMethod(method, left, []object.Object{right}, env), true
+	}
+	switch operator {
+	case "==", "!=":
+		receiver, other := left, right
+		method, ok := findMethod(left, "__eq__")
+		if !ok {
+			receiver, other = right, left
+			method, ok = findMethod(right, "__eq__")
+		}
+		if !ok {
+			return nil, false
+		}
+		return applyPredicateMethod(method, receiver, other, operator == "!=", env), true
+	case "<", ">", "<=", ">=":
+		receiver, other := left, right
+		if operator == ">" || operator == "<=" {
+			receiver, other = right, left
+		}
+		method, ok := findMethod(receiver, "__lt__")
+		if !ok {
+			return nil, false
+		}
+		negate := operator == "<=" || operator == ">="
+		return applyPredicateMethod(method, receiver, other, negate, env), true
+	}
+	return nil, false
+}
+
+// applyPredicateMethod calls a method and converts its result to a boolean,
+// negated if negate is true.
+func applyPredicateMethod(method, self, arg object.Object, negate bool, env *object.Env) object.Object {
+	result := applyMethod(method, self, []object.Object{arg}, env)
+	if isError(result) {
+		return result
+	}
+	return nativeBoolToBooleanObject(isTruthy(result) != negate)
+}
+
+// displayString renders an object for output as a string, using its __str__
+// method if it has one.
+func displayString(obj object.Object, env *object.Env) object.Object {
+	method, ok := findMethod(obj, "__str__")
+	if !ok {
+		return &object.String{
+			Value: obj.Inspect(),
+		}
+	}
+	str := applyMethod(method, obj, nil, env)
+	if isError(str) {
+		return str
+	}
+	if _, ok := str.(*object.String); !ok {
+		return newError("__str__ must return STRING, got %s", str.Type())
+	}
+	return str
+}
@@ -72,6 +72,13 @@ func convertObjectToASTNode(o object.Object) ast.Node {
 			Token: t,
 			Value: o.Value,
 		}
+	case *object.Null:
+		return &ast.NullLiteral{
+			Token: token.Token{
+				Type:    token.Null,
+				Literal: "null",
+			},
+		}
 	case *object.Quote:
 		return o.Node
 	// TODO: add the remaining types here
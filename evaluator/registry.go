@@ -0,0 +1,169 @@
+package evaluator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/rtfb/tarsier/object"
+)
+
+// Param describes a single parameter of a builtin function.
+type Param struct {
+	Name  string
+	Types []object.Type // accepted argument types, empty means any type
+}
+
+// BuiltinDef declares a builtin function. The argument count and types are
+// validated against Params before Fn is called, so Fn can safely type-assert
+// its arguments.
+type BuiltinDef struct {
+	Name     string
+	Params   []Param
+	Variadic bool // the last param accepts any number of arguments, even zero
+	Doc      string
+	Fn       object.BuiltinFunction
+}
+
+// builtins holds everything registered with RegisterBuiltins. A value is
+// either a *object.Builtin or a *object.Hash holding a namespace of them.
+var builtins = map[string]object.Object{}
+
+// RegisterBuiltin makes a builtin function available to all programs under
+// def.Name.
+func RegisterBuiltin(def BuiltinDef) error {
+	return RegisterBuiltins("", def)
+}
+
+// RegisterBuiltins makes builtin functions available to all programs. With an
+// empty namespace the builtins are bound to their names directly, otherwise
+// the namespace is bound to a hash that maps the names to builtins, so they
+// are called like `ns.name(...)`.
+//
+// Registration is not synchronized with evaluation, so it should be done
+// before any programs run, e.g. in an init function.
+func RegisterBuiltins(namespace string, defs ...BuiltinDef) error {
+	for _, def := range defs {
+		if err := validateDef(def); err != nil {
+			return err
+		}
+	}
+	if namespace == "" {
+		for _, def := range defs {
+			if _, ok := builtins[def.Name]; ok {
+				return fmt.Errorf("builtin %q already registered", def.Name)
+			}
+		}
+		for _, def := range defs {
+			builtins[def.Name] = newBuiltin(def)
+		}
+		return nil
+	}
+	hash := &object.Hash{}
+	if ns, ok := builtins[namespace]; ok {
+		if hash, ok = ns.(*object.Hash); !ok {
+			return fmt.Errorf("%q is already registered as a builtin", namespace)
+		}
+	}
+	for _, def := range defs {
+		if _, ok := hash.Get(&object.String{Value: def.Name}); ok {
+			return fmt.Errorf("builtin %q already registered in namespace %q",
+				def.Name, namespace)
+		}
+	}
+	for _, def := range defs {
+		hash.Set(&object.String{Value: def.Name}, newBuiltin(def))
+	}
+	builtins[namespace] = hash
+	return nil
+}
+
+// validateDef rejects definitions that newBuiltin can't turn into a working
+// builtin.
+func validateDef(def BuiltinDef) error {
+	if def.Fn == nil {
+		return fmt.Errorf("builtin %q has no Fn", def.Name)
+	}
+	if def.Variadic && len(def.Params) == 0 {
+		return fmt.Errorf("variadic builtin %q has no Params", def.Name)
+	}
+	return nil
+}
+
+func newBuiltin(def BuiltinDef) *object.Builtin {
+	return &object.Builtin{
+		Name: def.Name,
+		Doc:  signature(def) + "\n\n" + def.Doc,
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			if errObj := checkArgs(def, args); errObj != nil {
+				return errObj
+			}
+			return def.Fn(ctx, args...)
+		},
+	}
+}
+
+func checkArgs(def BuiltinDef, args []object.Object) *object.Error {
+	numParams := len(def.Params)
+	if def.Variadic {
+		if len(args) < numParams-1 {
+			return newError("wrong number of arguments, got=%d, want at least %d",
+				len(args), numParams-1)
+		}
+	} else if len(args) != numParams {
+		return newError("wrong number of arguments, got=%d, want=%d",
+			len(args), numParams)
+	}
+	for i, arg := range args {
+		param := def.Params[numParams-1]
+		if i < numParams {
+			param = def.Params[i]
+		}
+		if !acceptsType(param, arg.Type()) {
+			return newError("argument to `%s` must be %s, got %s",
+				def.Name, typeList(param.Types), arg.Type())
+		}
+	}
+	return nil
+}
+
+func acceptsType(param Param, typ object.Type) bool {
+	if len(param.Types) == 0 {
+		return true
+	}
+	for _, t := range param.Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// signature renders a builtin's declaration, e.g. `push(arr: ARRAY, el)`.
+func signature(def BuiltinDef) string {
+	var out bytes.Buffer
+	params := make([]string, len(def.Params))
+	for i, p := range def.Params {
+		params[i] = p.Name
+		if len(p.Types) > 0 {
+			params[i] += ": " + typeList(p.Types)
+		}
+		if def.Variadic && i == len(def.Params)-1 {
+			params[i] = "..." + params[i]
+		}
+	}
+	out.WriteString(def.Name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// typeList renders the types a param accepts, e.g. `STRING|ARRAY`.
+func typeList(types []object.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, "|")
+}
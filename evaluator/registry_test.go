@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/rtfb/tarsier/object"
+)
+
+func TestRegisterBuiltins(t *testing.T) {
+	// the registry is global, restore it so that the test can run repeatedly:
+	saved := make(map[string]object.Object, len(builtins))
+	for name, builtin := range builtins {
+		saved[name] = builtin
+	}
+	t.Cleanup(func() {
+		builtins = saved
+	})
+	strType := []object.Type{object.ObjTypeString}
+	join := BuiltinDef{
+		Name:     "join",
+		Params:   []Param{{"sep", strType}, {"strs", strType}},
+		Variadic: true,
+		Doc:      "Joins strings with a separator.",
+		Fn: func(ctx *object.BuiltinContext, args ...object.Object) object.Object {
+			result := ""
+			for i, arg := range args[1:] {
+				if i > 0 {
+					result += args[0].(*object.String).Value
+				}
+				result += arg.(*object.String).Value
+			}
+			return &object.String{Value: result}
+		},
+	}
+	if err := RegisterBuiltin(BuiltinDef{Name: "test_join", Params: join.Params, Variadic: true, Fn: join.Fn}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RegisterBuiltins("teststr", join); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RegisterBuiltin(BuiltinDef{Name: "len", Fn: join.Fn}); err == nil {
+		t.Errorf("expected an error when registering a duplicate builtin")
+	}
+	if err := RegisterBuiltins("teststr", BuiltinDef{Name: "join", Fn: join.Fn}); err == nil {
+		t.Errorf("expected an error when registering a duplicate namespaced builtin")
+	}
+	if err := RegisterBuiltin(BuiltinDef{Name: "test_nofn"}); err == nil {
+		t.Errorf("expected an error when registering a builtin without Fn")
+	}
+	if err := RegisterBuiltin(BuiltinDef{Name: "test_noparams", Variadic: true, Fn: join.Fn}); err == nil {
+		t.Errorf("expected an error when registering a variadic builtin without Params")
+	}
+	if _, ok := builtins["test_noparams"]; ok {
+		t.Errorf("invalid builtin was registered")
+	}
+	if err := RegisterBuiltins("len", join); err == nil {
+		t.Errorf("expected an error when namespace clashes with a builtin")
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`test_join(", ", "a", "b", "c")`, "a, b, c"},
+		{`test_join(", ")`, ""},
+		{`teststr["join"]("-", "a", "b")`, "a-b"},
+		{`teststr.join("-", "a", "b")`, "a-b"},
+		{`doc(teststr["join"])`, "join(sep: STRING, ...strs: STRING)\n\nJoins strings with a separator."},
+		{`doc(push)`, "push(arr: ARRAY, el)\n\nReturns a new array with el appended to the end of arr."},
+		{`test_join()`, "ERROR: wrong number of arguments, got=0, want at least 1"},
+		{`test_join(",", "a", 1)`, "ERROR: argument to `test_join` must be STRING, got INTEGER"},
+		{`first(1)`, "ERROR: argument to `first` must be ARRAY, got INTEGER"},
+		{`map([1], 1)`, "ERROR: argument to `map` must be FUNCTION|BUILTIN|STRUCT, got INTEGER"},
+		{`push([])`, "ERROR: wrong number of arguments, got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.want {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.want, evaluated.Inspect())
+		}
+	}
+}
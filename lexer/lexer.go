@@ -1,6 +1,10 @@
 package lexer
 
-import "github.com/rtfb/tarsier/token"
+import (
+	"strings"
+
+	"github.com/rtfb/tarsier/token"
+)
 
 // Lexer manages the lexical analysis of the input stream.
 type Lexer struct {
@@ -28,6 +32,9 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.Equals, Literal: literal}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.Arrow, Literal: "=>"}
 		} else {
 			tok = newToken(token.Assign, l.ch)
 		}
@@ -67,9 +74,49 @@ func (l *Lexer) NextToken() token.Token {
 	case '*':
 		tok = newToken(token.Asterisk, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.LTE, Literal: literal}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.GTE, Literal: literal}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.Pipe, Literal: "|>"}
+		} else {
+			tok = newToken(token.Illegal, l.ch)
+		}
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.Ellipsis, Literal: "..."}
+		} else {
+			tok = newToken(token.Dot, l.ch)
+		}
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.Coalesce, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OptChain, Literal: "?."}
+		default:
+			tok = newToken(token.Illegal, l.ch)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -31,6 +31,16 @@ if (5 < 10) {
 [1, 2];
 {"foo": "bar"}
 macro(x, y) { x + y; };
+"a" <= "b" >= "c" in "abc";
+null ?? a?.[0] ?;
+const c = 1;
+x => x;
+x |> f() | y;
+f(...xs) . ..;
+match (x) { _ => 1 };
+a.b?.c;
+struct P { x };
+enum E { A };
 `
 	tests := []struct {
 		wantType    token.Type
@@ -135,6 +145,77 @@ macro(x, y) { x + y; };
 		{token.Semicolon, ";"},
 		{token.RBrace, "}"},
 		{token.Semicolon, ";"},
+		{token.String, "a"},
+		{token.LTE, "<="},
+		{token.String, "b"},
+		{token.GTE, ">="},
+		{token.String, "c"},
+		{token.In, "in"},
+		{token.String, "abc"},
+		{token.Semicolon, ";"},
+		{token.Null, "null"},
+		{token.Coalesce, "??"},
+		{token.Ident, "a"},
+		{token.OptChain, "?."},
+		{token.LBracket, "["},
+		{token.Num, "0"},
+		{token.RBracket, "]"},
+		{token.Illegal, "?"},
+		{token.Semicolon, ";"},
+		{token.Const, "const"},
+		{token.Ident, "c"},
+		{token.Assign, "="},
+		{token.Num, "1"},
+		{token.Semicolon, ";"},
+		{token.Ident, "x"},
+		{token.Arrow, "=>"},
+		{token.Ident, "x"},
+		{token.Semicolon, ";"},
+		{token.Ident, "x"},
+		{token.Pipe, "|>"},
+		{token.Ident, "f"},
+		{token.LParen, "("},
+		{token.RParen, ")"},
+		{token.Illegal, "|"},
+		{token.Ident, "y"},
+		{token.Semicolon, ";"},
+		{token.Ident, "f"},
+		{token.LParen, "("},
+		{token.Ellipsis, "..."},
+		{token.Ident, "xs"},
+		{token.RParen, ")"},
+		{token.Dot, "."},
+		{token.Dot, "."},
+		{token.Dot, "."},
+		{token.Semicolon, ";"},
+		{token.Match, "match"},
+		{token.LParen, "("},
+		{token.Ident, "x"},
+		{token.RParen, ")"},
+		{token.LBrace, "{"},
+		{token.Ident, "_"},
+		{token.Arrow, "=>"},
+		{token.Num, "1"},
+		{token.RBrace, "}"},
+		{token.Semicolon, ";"},
+		{token.Ident, "a"},
+		{token.Dot, "."},
+		{token.Ident, "b"},
+		{token.OptChain, "?."},
+		{token.Ident, "c"},
+		{token.Semicolon, ";"},
+		{token.Struct, "struct"},
+		{token.Ident, "P"},
+		{token.LBrace, "{"},
+		{token.Ident, "x"},
+		{token.RBrace, "}"},
+		{token.Semicolon, ";"},
+		{token.Enum, "enum"},
+		{token.Ident, "E"},
+		{token.LBrace, "{"},
+		{token.Ident, "A"},
+		{token.RBrace, "}"},
+		{token.Semicolon, ";"},
 		{token.EOF, ""},
 	}
 	l := New(input)
@@ -1,9 +1,17 @@
 package object
 
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
 // Env holds the execution environment.
 type Env struct {
-	store map[string]Object
-	outer *Env
+	store  map[string]Object
+	consts map[string]bool // names in store that are bound as constants
+	outer  *Env
+	out    io.Writer
 }
 
 // NewEnv creates an Env.
@@ -28,8 +36,48 @@ func (e *Env) Get(name string) (Object, bool) {
 	return val, ok
 }
 
-// Set associates (binds) a given object with a given name.
+// IsTopLevel reports whether e is not enclosed in another env.
+func (e *Env) IsTopLevel() bool {
+	return e.outer == nil
+}
+
+// Set associates (binds) a given object with a given name. It doesn't check
+// for constants, use Declare for that.
 func (e *Env) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// Declare binds a given object to a given name, as a constant if constant is
+// true. Unlike Set, it refuses to rebind a name that is already bound to a
+// constant in this env; outer envs can still be shadowed.
+func (e *Env) Declare(name string, val Object, constant bool) error {
+	if e.consts[name] {
+		return fmt.Errorf("cannot redeclare constant %q", name)
+	}
+	if constant {
+		if e.consts == nil {
+			e.consts = make(map[string]bool)
+		}
+		e.consts[name] = true
+	}
+	e.store[name] = val
+	return nil
+}
+
+// Out returns the stream the program output should be written to. Enclosed
+// envs inherit it from the outer ones, the default is os.Stdout.
+func (e *Env) Out() io.Writer {
+	if e.out != nil {
+		return e.out
+	}
+	if e.outer != nil {
+		return e.outer.Out()
+	}
+	return os.Stdout
+}
+
+// SetOut redirects the program output to a given stream.
+func (e *Env) SetOut(out io.Writer) {
+	e.out = out
+}
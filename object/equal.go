@@ -0,0 +1,70 @@
+package object
+
+// Equal reports whether two objects are equal by value. Arrays, tuples, hashes
+// and records are compared element by element, records only if they are of the
+// same struct. Nulls are all equal to each other. Numbers compare by their
+// numeric value; Integer is the only numeric type so far. Objects without a
+// value semantics, like functions, are only equal to themselves.
+func Equal(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		b, ok := b.(*Integer)
+		return ok && a.Value == b.Value
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i, el := range a.Elements {
+			if !Equal(el, b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i, el := range a.Elements {
+			if !Equal(el, b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key)
+			if !ok || !Equal(pair.Value, other) {
+				return false
+			}
+		}
+		return true
+	case *Record:
+		b, ok := b.(*Record)
+		if !ok || a.Struct != b.Struct {
+			return false
+		}
+		for i, val := range a.Values {
+			if !Equal(val, b.Values[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
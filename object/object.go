@@ -2,9 +2,13 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash"
 	"hash/fnv"
+	"io"
 	"strings"
+	"sync/atomic"
 
 	"github.com/rtfb/tarsier/ast"
 )
@@ -23,6 +27,10 @@ const (
 	ObjTypeHash        = "HASH"
 	ObjTypeQuote       = "QUOTE"
 	ObjTypeMacro       = "MACRO"
+	ObjTypeStruct      = "STRUCT"
+	ObjTypeRecord      = "RECORD"
+	ObjTypeEnum        = "ENUM"
+	ObjTypeTuple       = "TUPLE"
 )
 
 // HashKey contains a hash sum.
@@ -32,10 +40,67 @@ type HashKey struct {
 }
 
 // Hashable describes an interface for objects that can be hashed.
+//
+// Composite objects (arrays, tuples, hashes and records) are hashed by value,
+// at the time they are stored as a key. Tarsier programs can't modify them, so
+// that's safe, but Go code that modifies a composite after using it as a key
+// leaves the hash it's stored in inconsistent. Use AsHashable to check whether
+// an object can be used as a key, since composites are only hashable if all of
+// their contents are.
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
+// AsHashable returns o as a Hashable if it can be used as a hash key.
+func AsHashable(o Object) (Hashable, bool) {
+	switch o := o.(type) {
+	case *Array:
+		for _, el := range o.Elements {
+			if _, ok := AsHashable(el); !ok {
+				return nil, false
+			}
+		}
+	case *Hash:
+		for _, pair := range o.pairs {
+			if _, ok := AsHashable(pair.Value); !ok {
+				return nil, false
+			}
+		}
+	case *Tuple:
+		for _, el := range o.Elements {
+			if _, ok := AsHashable(el); !ok {
+				return nil, false
+			}
+		}
+	case *Record:
+		for _, val := range o.Values {
+			if _, ok := AsHashable(val); !ok {
+				return nil, false
+			}
+		}
+	}
+	h, ok := o.(Hashable)
+	return h, ok
+}
+
+// combineHashKeys mixes a hash key into a running hash sum.
+func combineHashKeys(h hash.Hash64, key HashKey) {
+	var buf [8]byte
+	h.Write([]byte(key.Type))
+	binary.LittleEndian.PutUint64(buf[:], key.Value)
+	h.Write(buf[:])
+}
+
+// hashKeyOf hashes an element of a composite. The ones that are not hashable
+// only contribute their type.
+func hashKeyOf(o Object) HashKey {
+	if h, ok := o.(Hashable); ok {
+		return h.HashKey()
+	}
+	return HashKey{Type: o.Type()}
+}
+
 // Type is an identifier for a type of an object.
 type Type string
 
@@ -169,6 +234,7 @@ func (e *Error) Inspect() string {
 
 // Function represents a function object.
 type Function struct {
+	Name       string // empty for anonymous functions
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Env
@@ -186,7 +252,11 @@ func (f *Function) Inspect() string {
 	for i, p := range f.Parameters {
 		params[i] = p.String()
 	}
-	out.WriteString("fn(")
+	out.WriteString("fn")
+	if f.Name != "" {
+		out.WriteString(" " + f.Name)
+	}
+	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
@@ -194,12 +264,22 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// BuiltinContext gives a built-in function access to the state of the
+// evaluator at the call site.
+type BuiltinContext struct {
+	Env   *Env                                   // the env the builtin is called from
+	Out   io.Writer                              // the stream for program output
+	Apply func(fn Object, args ...Object) Object // applies a function to args
+}
+
 // BuiltinFunction is a signature for implementation of any built-in function.
-type BuiltinFunction func(args ...Object) Object
+type BuiltinFunction func(ctx *BuiltinContext, args ...Object) Object
 
 // Builtin represents a language-provided built-in function.
 type Builtin struct {
-	Fn BuiltinFunction
+	Name string
+	Doc  string
+	Fn   BuiltinFunction
 }
 
 // Type implements Object.
@@ -209,12 +289,25 @@ func (b *Builtin) Type() Type {
 
 // Inspect implements Object.
 func (b *Builtin) Inspect() string {
-	return "builtin function"
+	return "builtin function " + b.Name
 }
 
 // Array is an object containing an ordered list of other objects.
+//
+// Arrays are immutable and Push and Rest share the storage between the
+// original array and the one they create, so Elements must never be modified
+// in place. Push and Rest never change the elements of existing arrays, so
+// arrays are safe for concurrent use.
 type Array struct {
 	Elements []Object
+	storage  *arrayStorage // nil if Elements is not shared with other arrays
+}
+
+// arrayStorage describes the backing array of Elements, shared by all the
+// arrays derived from one another with Push and Rest.
+type arrayStorage struct {
+	used int64 // number of slots of the backing array taken by some array
+	cap  int   // capacity of the backing array
 }
 
 // Type implements Object.
@@ -235,14 +328,114 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
-// HashPair represents a
+// HashKey returns a hashed value for an array, combining the hashes of its
+// elements.
+func (a *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	for _, el := range a.Elements {
+		combineHashKeys(h, hashKeyOf(el))
+	}
+	return HashKey{
+		Type:  a.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// Push returns a new array with el appended. When a is the array that took the
+// last used slot of its storage, el is put in the next slot in place, making
+// repeated pushes amortized O(1). Otherwise the elements are copied to a new,
+// larger storage. The slot is claimed atomically, so that concurrent pushes
+// to the same array never write to the same slot.
+func (a *Array) Push(el Object) *Array {
+	length := len(a.Elements)
+	if st := a.storage; st != nil && length < cap(a.Elements) {
+		end := int64(st.cap - cap(a.Elements) + length)
+		if atomic.CompareAndSwapInt64(&st.used, end, end+1) {
+			return &Array{
+				Elements: append(a.Elements, el),
+				storage:  st,
+			}
+		}
+	}
+	capacity := 2 * length
+	if capacity < 4 {
+		capacity = 4
+	}
+	elements := make([]Object, length+1, capacity)
+	copy(elements, a.Elements)
+	elements[length] = el
+	return &Array{
+		Elements: elements,
+		storage: &arrayStorage{
+			used: int64(length + 1),
+			cap:  capacity,
+		},
+	}
+}
+
+// Rest returns a new array with all elements but the first one in O(1) by
+// sharing the storage with a. It must not be called on an empty array.
+func (a *Array) Rest() *Array {
+	return &Array{
+		Elements: a.Elements[1:],
+		storage:  a.storage,
+	}
+}
+
+// Tuple is an immutable, fixed-size sequence of objects.
+type Tuple struct {
+	Elements []Object
+}
+
+// Type implements Object.
+func (t *Tuple) Type() Type {
+	return ObjTypeTuple
+}
+
+// Inspect implements Object.
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+	elements := make([]string, len(t.Elements))
+	for i, e := range t.Elements {
+		elements[i] = e.Inspect()
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	if len(elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+// HashKey returns a hashed value for a tuple, combining the hashes of its
+// elements.
+func (t *Tuple) HashKey() HashKey {
+	h := fnv.New64a()
+	for _, el := range t.Elements {
+		combineHashKeys(h, hashKeyOf(el))
+	}
+	return HashKey{
+		Type:  t.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// HashPair represents a key-value pair stored in a Hash.
 type HashPair struct {
-	Key   Object
+	Key   Hashable
 	Value Object
 }
 
+// Hash is an object mapping keys to values. It remembers the order in which
+// the keys were first inserted and iterates over them in that order. The zero
+// value is an empty hash.
+//
+// HashKey only narrows down the candidates: keys whose hash sums collide are
+// kept in the same bucket and told apart with Equal.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	pairs []HashPair
+	index map[HashKey][]int // positions in pairs of the keys with that hash sum
 }
 
 // Type implements Object.
@@ -253,8 +446,8 @@ func (h *Hash) Type() Type {
 // Inspect implements Object.
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
-	pairs := make([]string, 0, len(h.Pairs))
-	for _, pair := range h.Pairs {
+	pairs := make([]string, 0, len(h.pairs))
+	for _, pair := range h.pairs {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(),
 			pair.Value.Inspect()))
 	}
@@ -264,6 +457,67 @@ func (h *Hash) Inspect() string {
 	return out.String()
 }
 
+// HashKey returns a hashed value for a hash. It doesn't depend on the order of
+// the pairs, since it doesn't matter for equality either.
+func (h *Hash) HashKey() HashKey {
+	var sum uint64
+	for _, pair := range h.pairs {
+		pairHash := fnv.New64a()
+		combineHashKeys(pairHash, pair.Key.HashKey())
+		combineHashKeys(pairHash, hashKeyOf(pair.Value))
+		sum += pairHash.Sum64()
+	}
+	return HashKey{
+		Type:  h.Type(),
+		Value: sum,
+	}
+}
+
+// Get looks up the value stored under a given key.
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	i := h.find(key, key.HashKey())
+	if i < 0 {
+		return nil, false
+	}
+	return h.pairs[i].Value, true
+}
+
+// Set stores a value under a given key. Overwriting the value of an existing
+// key keeps its original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	hashed := key.HashKey()
+	if i := h.find(key, hashed); i >= 0 {
+		h.pairs[i].Value = value
+		return
+	}
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+	h.index[hashed] = append(h.index[hashed], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+// find returns the position of key in pairs, or -1 if it's not there.
+func (h *Hash) find(key Hashable, hashed HashKey) int {
+	for _, i := range h.index[hashed] {
+		if Equal(h.pairs[i].Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len returns the number of pairs in the hash.
+func (h *Hash) Len() int {
+	return len(h.pairs)
+}
+
+// Pairs returns the pairs of the hash in insertion order. The returned slice
+// must not be modified.
+func (h *Hash) Pairs() []HashPair {
+	return h.pairs
+}
+
 // Quote encapsulates an AST node in an object.
 type Quote struct {
 	Node ast.Node
@@ -305,3 +559,157 @@ func (m *Macro) Inspect() string {
 	out.WriteString("\n}")
 	return out.String()
 }
+
+// Struct is a record type declared with a struct statement. Calling it
+// constructs a Record with the arguments as the values of the fields.
+//
+// The variants of an enum are structs too, with Enum set. Their records are
+// the values of the enum, Name being their tag and Values their payload.
+type Struct struct {
+	Name    string
+	Fields  []string
+	Methods map[string]Object
+	Enum    *Enum // the enum this struct is a variant of, nil for plain structs
+}
+
+// Type implements Object.
+func (s *Struct) Type() Type {
+	return ObjTypeStruct
+}
+
+// Inspect implements Object.
+func (s *Struct) Inspect() string {
+	if s.Enum != nil {
+		return s.Enum.Name + "." + variantString(s)
+	}
+	if len(s.Fields) == 0 {
+		return "struct " + s.Name + " {}"
+	}
+	return "struct " + s.Name + " { " + strings.Join(s.Fields, ", ") + " }"
+}
+
+// FieldIndex returns the position of a field in Fields, or -1 if the struct
+// has no such field.
+func (s *Struct) FieldIndex(field string) int {
+	for i, f := range s.Fields {
+		if f == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// Record is a value of a Struct type. Records are immutable, With returns an
+// updated copy.
+type Record struct {
+	Struct *Struct
+	Values []Object // the values of Struct.Fields, in the same order
+}
+
+// Type implements Object.
+func (r *Record) Type() Type {
+	return ObjTypeRecord
+}
+
+// Inspect implements Object.
+func (r *Record) Inspect() string {
+	var out bytes.Buffer
+	fields := make([]string, len(r.Values))
+	for i, val := range r.Values {
+		fields[i] = r.Struct.Fields[i] + ": " + val.Inspect()
+	}
+	if r.Struct.Enum != nil && len(r.Values) == 0 {
+		return r.Struct.Name
+	}
+	out.WriteString(r.Struct.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// HashKey returns a hashed value for a record, combining its type name and
+// the hashes of its values.
+func (r *Record) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(r.Struct.Name))
+	for _, val := range r.Values {
+		combineHashKeys(h, hashKeyOf(val))
+	}
+	return HashKey{
+		Type:  r.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// Get returns the value of a field.
+func (r *Record) Get(field string) (Object, bool) {
+	i := r.Struct.FieldIndex(field)
+	if i < 0 {
+		return nil, false
+	}
+	return r.Values[i], true
+}
+
+// With returns a copy of r with a field set to val. It reports false if the
+// record has no such field.
+func (r *Record) With(field string, val Object) (*Record, bool) {
+	i := r.Struct.FieldIndex(field)
+	if i < 0 {
+		return nil, false
+	}
+	values := make([]Object, len(r.Values))
+	copy(values, r.Values)
+	values[i] = val
+	return &Record{
+		Struct: r.Struct,
+		Values: values,
+	}, true
+}
+
+// Enum is a tagged union type declared with an enum statement. Its values are
+// the records of its variants.
+type Enum struct {
+	Name     string
+	Variants []*Struct
+}
+
+// Type implements Object.
+func (e *Enum) Type() Type {
+	return ObjTypeEnum
+}
+
+// Inspect implements Object.
+func (e *Enum) Inspect() string {
+	variants := make([]string, len(e.Variants))
+	for i, v := range e.Variants {
+		variants[i] = variantString(v)
+	}
+	if len(variants) == 0 {
+		return "enum " + e.Name + " {}"
+	}
+	return "enum " + e.Name + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Variant returns what a variant of the enum is bound to: its constructor, or
+// its only value for a unit variant (one without fields).
+func (e *Enum) Variant(tag string) (Object, bool) {
+	for _, v := range e.Variants {
+		if v.Name != tag {
+			continue
+		}
+		if len(v.Fields) == 0 {
+			return &Record{Struct: v}, true
+		}
+		return v, true
+	}
+	return nil, false
+}
+
+// variantString renders a variant the way it's declared, e.g. `Circle(r)`.
+func variantString(s *Struct) string {
+	if len(s.Fields) == 0 {
+		return s.Name
+	}
+	return s.Name + "(" + strings.Join(s.Fields, ", ") + ")"
+}
@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +21,326 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestEqual(t *testing.T) {
+	one := &Integer{Value: 1}
+	fn := &Builtin{}
+	hash := func(pairs ...Object) *Hash {
+		h := &Hash{}
+		for i := 0; i < len(pairs); i += 2 {
+			h.Set(pairs[i].(Hashable), pairs[i+1])
+		}
+		return h
+	}
+	tests := []struct {
+		a, b Object
+		want bool
+	}{
+		{one, &Integer{Value: 1}, true},
+		{one, &Integer{Value: 2}, false},
+		{one, &String{Value: "1"}, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&Boolean{Value: true}, &Boolean{Value: true}, true},
+		{&Null{}, &Null{}, true},
+		{&Null{}, &Boolean{Value: false}, false},
+		{&Array{Elements: []Object{one, &Array{}}}, &Array{Elements: []Object{&Integer{Value: 1}, &Array{}}}, true},
+		{&Array{Elements: []Object{one}}, &Array{Elements: []Object{one, one}}, false},
+		{&Array{Elements: []Object{one}}, &Array{Elements: []Object{&String{Value: "1"}}}, false},
+		{hash(one, one, &String{Value: "a"}, one), hash(&String{Value: "a"}, one, one, &Integer{Value: 1}), true},
+		{hash(one, one), hash(one, &Integer{Value: 2}), false},
+		{hash(one, one), hash(one, one, &String{Value: "a"}, one), false},
+		{&Tuple{Elements: []Object{one, &Tuple{}}}, &Tuple{Elements: []Object{&Integer{Value: 1}, &Tuple{}}}, true},
+		{&Tuple{Elements: []Object{one}}, &Tuple{Elements: []Object{one, one}}, false},
+		{&Tuple{Elements: []Object{one}}, &Array{Elements: []Object{one}}, false},
+		{fn, fn, true},
+		{fn, &Builtin{}, false},
+	}
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("tests[%d]: Equal(%s, %s) want=%t, got=%t", i, tt.a.Inspect(),
+				tt.b.Inspect(), tt.want, got)
+		}
+	}
+}
+
+// collidingKey is a hashable object whose hash sums always collide.
+type collidingKey struct {
+	name string
+}
+
+func (c *collidingKey) Type() Type {
+	return "COLLIDING_KEY"
+}
+
+func (c *collidingKey) Inspect() string {
+	return c.name
+}
+
+func (c *collidingKey) HashKey() HashKey {
+	return HashKey{Type: c.Type(), Value: 42}
+}
+
+func TestHashCollisions(t *testing.T) {
+	a := &collidingKey{name: "a"}
+	b := &collidingKey{name: "b"}
+	if a.HashKey() != b.HashKey() {
+		t.Fatalf("keys are expected to collide")
+	}
+	h := &Hash{}
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+	h.Set(a, &Integer{Value: 3})
+	if h.Len() != 2 {
+		t.Fatalf("wrong number of pairs, want=2, got=%d", h.Len())
+	}
+	tests := []struct {
+		key  Hashable
+		want int64
+	}{
+		{a, 3},
+		{b, 2},
+	}
+	for _, tt := range tests {
+		got, ok := h.Get(tt.key)
+		if !ok {
+			t.Errorf("no value for key %s", tt.key.Inspect())
+			continue
+		}
+		if got.(*Integer).Value != tt.want {
+			t.Errorf("wrong value for key %s, want=%d, got=%s", tt.key.Inspect(),
+				tt.want, got.Inspect())
+		}
+	}
+	if _, ok := h.Get(&collidingKey{name: "c"}); ok {
+		t.Errorf("found a value for a key that was never set")
+	}
+	if h.Inspect() != "{a: 3, b: 2}" {
+		t.Errorf("wrong Inspect, got=%q", h.Inspect())
+	}
+}
+
+func TestCompositeHashKey(t *testing.T) {
+	arr := func(elements ...Object) *Array {
+		return &Array{Elements: elements}
+	}
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+	h1 := &Hash{}
+	h1.Set(one, two)
+	h1.Set(&String{Value: "a"}, arr(one))
+	h2 := &Hash{}
+	h2.Set(&String{Value: "a"}, arr(&Integer{Value: 1}))
+	h2.Set(&Integer{Value: 1}, &Integer{Value: 2})
+	if arr(one, two).HashKey() != arr(&Integer{Value: 1}, &Integer{Value: 2}).HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+	if arr(one, two).HashKey() == arr(two, one).HashKey() {
+		t.Errorf("arrays with different content have same hash keys")
+	}
+	if arr(arr(one), two).HashKey() == arr(arr(one, two)).HashKey() {
+		t.Errorf("arrays with different nesting have same hash keys")
+	}
+	tup := func(elements ...Object) *Tuple {
+		return &Tuple{Elements: elements}
+	}
+	if tup(one, two).HashKey() != tup(&Integer{Value: 1}, &Integer{Value: 2}).HashKey() {
+		t.Errorf("tuples with same content have different hash keys")
+	}
+	if tup(one, two).HashKey() == tup(two, one).HashKey() {
+		t.Errorf("tuples with different content have same hash keys")
+	}
+	if tup(one, two).HashKey() == arr(one, two).HashKey() {
+		t.Errorf("a tuple and an array with same content have same hash keys")
+	}
+	if h1.HashKey() != h2.HashKey() {
+		t.Errorf("hashes with same content have different hash keys")
+	}
+	h2.Set(one, one)
+	if h1.HashKey() == h2.HashKey() {
+		t.Errorf("hashes with different content have same hash keys")
+	}
+	tests := []struct {
+		obj  Object
+		want bool
+	}{
+		{one, true},
+		{arr(one, arr(two)), true},
+		{arr(one, &Builtin{}), false},
+		{tup(one, arr(two)), true},
+		{tup(&Builtin{}), false},
+		{h1, true},
+		{&Builtin{}, false},
+	}
+	for i, tt := range tests {
+		if _, ok := AsHashable(tt.obj); ok != tt.want {
+			t.Errorf("tests[%d]: AsHashable(%s) want=%t, got=%t", i, tt.obj.Inspect(),
+				tt.want, ok)
+		}
+	}
+	fnHash := &Hash{}
+	fnHash.Set(one, &Builtin{})
+	if _, ok := AsHashable(fnHash); ok {
+		t.Errorf("hash with a builtin value is hashable")
+	}
+}
+
+func TestArrayPushRest(t *testing.T) {
+	ints := func(arr *Array) []int64 {
+		result := make([]int64, len(arr.Elements))
+		for i, el := range arr.Elements {
+			result[i] = el.(*Integer).Value
+		}
+		return result
+	}
+	check := func(name string, arr *Array, want ...int64) {
+		got := ints(arr)
+		if len(got) != len(want) {
+			t.Errorf("%s: want=%v, got=%v", name, want, got)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: want=%v, got=%v", name, want, got)
+				return
+			}
+		}
+	}
+	base := &Array{Elements: []Object{&Integer{Value: 1}}}
+	a := base.Push(&Integer{Value: 2})
+	b := a.Push(&Integer{Value: 3})
+	c := a.Push(&Integer{Value: 4})
+	d := b.Rest().Push(&Integer{Value: 5})
+	e := b.Push(&Integer{Value: 6})
+	check("base", base, 1)
+	check("a", a, 1, 2)
+	check("b", b, 1, 2, 3)
+	check("c", c, 1, 2, 4)
+	check("d", d, 2, 3, 5)
+	check("e", e, 1, 2, 3, 6)
+	if &b.Elements[0] != &a.Elements[0] {
+		t.Errorf("push on the last array did not share the storage")
+	}
+	if &c.Elements[0] == &a.Elements[0] {
+		t.Errorf("push on a shared array did not copy the storage")
+	}
+	if &d.Elements[0] != &b.Elements[1] {
+		t.Errorf("push on the rest of the last array did not share the storage")
+	}
+	arr := &Array{}
+	for i := int64(0); i < 100; i++ {
+		arr = arr.Push(&Integer{Value: i})
+	}
+	for i := int64(0); i < 100; i++ {
+		if arr.Elements[0].(*Integer).Value != i {
+			t.Fatalf("wrong first element, want=%d, got=%s", i, arr.Elements[0].Inspect())
+		}
+		arr = arr.Rest()
+	}
+	if len(arr.Elements) != 0 {
+		t.Errorf("array not empty, got=%s", arr.Inspect())
+	}
+}
+
+func TestArrayConcurrentPush(t *testing.T) {
+	base := (&Array{}).Push(&Integer{Value: 0})
+	results := make([]*Array, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = base.Push(&Integer{Value: int64(i)})
+		}(i)
+	}
+	wg.Wait()
+	shared := 0
+	for i, arr := range results {
+		if got := arr.Inspect(); got != fmt.Sprintf("[0, %d]", i) {
+			t.Errorf("wrong elements, want=[0, %d], got=%s", i, got)
+		}
+		if &arr.Elements[0] == &base.Elements[0] {
+			shared++
+		}
+	}
+	if shared != 1 {
+		t.Errorf("want exactly one push to share the storage, got=%d", shared)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	point := &Struct{Name: "Point", Fields: []string{"x", "y"}}
+	other := &Struct{Name: "Point", Fields: []string{"x", "y"}}
+	rec := func(s *Struct, values ...Object) *Record {
+		return &Record{Struct: s, Values: values}
+	}
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+	p := rec(point, one, two)
+	if got := p.Inspect(); got != "Point{x: 1, y: 2}" {
+		t.Errorf("wrong Inspect, got=%q", got)
+	}
+	if got := point.Inspect(); got != "struct Point { x, y }" {
+		t.Errorf("wrong struct Inspect, got=%q", got)
+	}
+	if !Equal(p, rec(point, &Integer{Value: 1}, &Integer{Value: 2})) {
+		t.Errorf("records with same struct and values are not equal")
+	}
+	if Equal(p, rec(point, two, one)) {
+		t.Errorf("records with different values are equal")
+	}
+	if Equal(p, rec(other, one, two)) {
+		t.Errorf("records of different structs are equal")
+	}
+	if p.HashKey() != rec(point, one, two).HashKey() {
+		t.Errorf("records with same content have different hash keys")
+	}
+	if p.HashKey() == rec(point, two, one).HashKey() {
+		t.Errorf("records with different content have same hash keys")
+	}
+	if _, ok := AsHashable(rec(point, one, &Builtin{})); ok {
+		t.Errorf("record with a builtin value is hashable")
+	}
+	q, ok := p.With("y", one)
+	if !ok || q.Inspect() != "Point{x: 1, y: 1}" || p.Inspect() != "Point{x: 1, y: 2}" {
+		t.Errorf("wrong With result, got=%v, %t, original=%s", q, ok, p.Inspect())
+	}
+	if _, ok := p.With("z", one); ok {
+		t.Errorf("With succeeded for an unknown field")
+	}
+	if val, ok := p.Get("x"); !ok || val != one {
+		t.Errorf("wrong Get result, got=%v, %t", val, ok)
+	}
+	if _, ok := p.Get("z"); ok {
+		t.Errorf("Get succeeded for an unknown field")
+	}
+}
+
+func TestEnums(t *testing.T) {
+	shape := &Enum{Name: "Shape"}
+	circle := &Struct{Name: "Circle", Fields: []string{"r"}, Enum: shape}
+	empty := &Struct{Name: "Empty", Enum: shape}
+	shape.Variants = []*Struct{circle, empty}
+	if got := shape.Inspect(); got != "enum Shape { Circle(r), Empty }" {
+		t.Errorf("wrong enum Inspect, got=%q", got)
+	}
+	if got := circle.Inspect(); got != "Shape.Circle(r)" {
+		t.Errorf("wrong variant Inspect, got=%q", got)
+	}
+	if v, ok := shape.Variant("Circle"); !ok || v != circle {
+		t.Errorf("wrong Circle variant, got=%v, %t", v, ok)
+	}
+	v, ok := shape.Variant("Empty")
+	if !ok || v.Inspect() != "Empty" {
+		t.Errorf("wrong Empty variant, got=%v, %t", v, ok)
+	}
+	if !Equal(v, &Record{Struct: empty}) {
+		t.Errorf("unit variant values are not equal")
+	}
+	if _, ok := shape.Variant("Square"); ok {
+		t.Errorf("Variant succeeded for an unknown variant")
+	}
+	c := &Record{Struct: circle, Values: []Object{&Integer{Value: 1}}}
+	if got := c.Inspect(); got != "Circle{r: 1}" {
+		t.Errorf("wrong variant record Inspect, got=%q", got)
+	}
+}
@@ -14,8 +14,10 @@ import (
 const (
 	_ int = iota
 	Lowest
+	Pipe        // |>
+	Coalesce    // ??
 	Equals      // ==
-	LessGreater // < or >
+	LessGreater // <, >, <=, >= or in
 	Sum         // +
 	Product     // *
 	Prefix      // -x or !x
@@ -24,16 +26,23 @@ const (
 )
 
 var precedences = map[token.Type]int{
+	token.Pipe:      Pipe,
+	token.Coalesce:  Coalesce,
 	token.Equals:    Equals,
 	token.NotEquals: Equals,
 	token.LT:        LessGreater,
 	token.GT:        LessGreater,
+	token.LTE:       LessGreater,
+	token.GTE:       LessGreater,
+	token.In:        LessGreater,
 	token.Plus:      Sum,
 	token.Minus:     Sum,
 	token.Slash:     Product,
 	token.Asterisk:  Product,
 	token.LParen:    Call,
 	token.LBracket:  Index,
+	token.OptChain:  Index,
+	token.Dot:       Index,
 }
 
 type (
@@ -76,6 +85,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LBracket, p.parseArrayLiteral)
 	p.registerPrefix(token.LBrace, p.parseHashLiteral)
 	p.registerPrefix(token.Macro, p.parseMacroLiteral)
+	p.registerPrefix(token.Null, p.parseNullLiteral)
+	p.registerPrefix(token.Match, p.parseMatchExpression)
 	// infix parse funcs:
 	p.registerInfix(token.Plus, p.parseInfixExpression)
 	p.registerInfix(token.Minus, p.parseInfixExpression)
@@ -85,8 +96,15 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NotEquals, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LTE, p.parseInfixExpression)
+	p.registerInfix(token.GTE, p.parseInfixExpression)
+	p.registerInfix(token.In, p.parseInfixExpression)
 	p.registerInfix(token.LParen, p.parseCallExpression)
 	p.registerInfix(token.LBracket, p.parseIndexExpression)
+	p.registerInfix(token.OptChain, p.parseOptionalChain)
+	p.registerInfix(token.Dot, p.parseDotExpression)
+	p.registerInfix(token.Coalesce, p.parseInfixExpression)
+	p.registerInfix(token.Pipe, p.parsePipeExpression)
 	// read two tokens so that curToken and peekToken are both set:
 	p.nextToken()
 	p.nextToken()
@@ -120,10 +138,19 @@ func (p *Parser) Errors() []string {
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.Let:
+	case token.Let, token.Const:
 		return p.parseLetStatement()
 	case token.Return:
 		return p.parseReturnStatement()
+	case token.Function:
+		if p.peekTokenIs(token.Ident) {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionStatement()
+	case token.Struct:
+		return p.parseStructStatement()
+	case token.Enum:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -133,6 +160,57 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := ast.LetStatement{
 		Token: p.curToken,
 	}
+	if p.peekTokenIs(token.LParen) {
+		p.nextToken()
+		stmt.Names = p.parseNameList(token.RParen, "name", stmt.TokenLiteral(), true)
+		if stmt.Names == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+	}
+	if !p.expectPeek(token.Assign) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(Lowest)
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+	return &stmt
+}
+
+func (p *Parser) parseFunctionStatement() ast.Statement {
+	stmt := ast.FunctionStatement{
+		Token: p.curToken,
+		Name: &ast.Identifier{
+			Token: p.peekToken,
+			Value: p.peekToken.Literal,
+		},
+	}
+	fn, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	stmt.Function = fn
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+	return &stmt
+}
+
+// parseStructStatement parses a struct declaration, `struct Point { x, y }`.
+// Methods are declared among the fields, e.g. `fn norm() { ... }`.
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := ast.StructStatement{
+		Token: p.curToken,
+	}
 	if !p.expectPeek(token.Ident) {
 		return nil
 	}
@@ -140,17 +218,144 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
-	if !p.expectPeek(token.Assign) {
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	stmt.Fields = []*ast.Identifier{}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBrace) {
+		name, kind := "", "field"
+		if p.peekTokenIs(token.Function) {
+			kind = "method"
+			p.nextToken()
+			method, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+			if !ok {
+				return nil
+			}
+			if method.Name == "" {
+				msg := fmt.Sprintf("method in struct %s must have a name", stmt.Name)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+			name = method.Name
+			stmt.Methods = append(stmt.Methods, method)
+		} else {
+			if !p.expectPeek(token.Ident) {
+				return nil
+			}
+			name = p.curToken.Literal
+			stmt.Fields = append(stmt.Fields, &ast.Identifier{
+				Token: p.curToken,
+				Value: name,
+			})
+		}
+		if seen[name] {
+			msg := fmt.Sprintf("duplicate %s %s in struct %s", kind, name, stmt.Name)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[name] = true
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+	return &stmt
+}
+
+// parseEnumStatement parses an enum declaration,
+// `enum Shape { Circle(r), Rect(w, h), Empty }`.
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := ast.EnumStatement{
+		Token:    p.curToken,
+		Variants: []*ast.EnumVariant{},
+	}
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBrace) {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		variant := &ast.EnumVariant{
+			Name: &ast.Identifier{
+				Token: p.curToken,
+				Value: p.curToken.Literal,
+			},
+			Fields: []*ast.Identifier{},
+		}
+		if seen[variant.Name.Value] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s", variant.Name, stmt.Name)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[variant.Name.Value] = true
+		if p.peekTokenIs(token.LParen) {
+			p.nextToken()
+			variant.Fields = p.parseNameList(token.RParen, "field", "variant "+variant.Name.Value, false)
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBrace) {
 		return nil
 	}
-	p.nextToken()
-	stmt.Value = p.parseExpression(Lowest)
 	if p.peekTokenIs(token.Semicolon) {
 		p.nextToken()
 	}
 	return &stmt
 }
 
+// parseNameList parses a list of distinct names up to the end token, like the
+// fields of an enum variant. The kind of the names and their owner are used in
+// errors. If blank is set, the blank name _ may repeat. It returns nil on
+// errors.
+func (p *Parser) parseNameList(end token.Type, kind, owner string, blank bool) []*ast.Identifier {
+	fields := []*ast.Identifier{}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(end) {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		field := &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+		if seen[field.Value] && !(blank && field.Value == "_") {
+			msg := fmt.Sprintf("duplicate %s %s in %s", kind, field, owner)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		seen[field.Value] = true
+		fields = append(fields, field)
+		if !p.peekTokenIs(end) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(end) {
+		return nil
+	}
+	return fields
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := ast.ReturnStatement{
 		Token: p.curToken,
@@ -193,10 +398,15 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{
+	ident := ast.Identifier{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
+	if p.peekTokenIs(token.Arrow) {
+		p.nextToken()
+		return p.parseArrowFunction([]*ast.Identifier{&ident})
+	}
+	return &ident
 }
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
@@ -241,11 +451,67 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return &exp
 }
 
+// parseDotExpression desugars member access `obj.field` into `obj["field"]`.
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	dotToken := p.curToken
+	if !p.peekTokenIsMemberName() {
+		p.peekError(token.Ident)
+		return nil
+	}
+	p.nextToken()
+	return p.parseMember(dotToken, left)
+}
+
+// peekTokenIsMemberName reports whether the next token can name a member. Any
+// identifier can, keywords included, e.g. `h.match`.
+func (p *Parser) peekTokenIsMemberName() bool {
+	return p.peekTokenIs(token.Ident) || token.IsKeyword(p.peekToken.Type)
+}
+
+// parseMember builds the index expression for member access, the current
+// token is the member name.
+func (p *Parser) parseMember(tok token.Token, left ast.Expression) *ast.IndexExpression {
+	return &ast.IndexExpression{
+		Token: tok,
+		Left:  left,
+		Index: &ast.StringLiteral{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		},
+	}
+}
+
+func (p *Parser) parseOptionalChain(left ast.Expression) ast.Expression {
+	optToken := p.curToken
+	if p.peekTokenIsMemberName() {
+		p.nextToken()
+		exp := p.parseMember(optToken, left)
+		exp.Optional = true
+		return exp
+	}
+	if !p.expectPeek(token.LBracket) {
+		return nil
+	}
+	exp, ok := p.parseIndexExpression(left).(*ast.IndexExpression)
+	if !ok {
+		return nil
+	}
+	exp.Token = optToken
+	exp.Optional = true
+	return exp
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{
+		Token: p.curToken,
+	}
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := ast.HashLiteral{
 		Token: p.curToken,
 	}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashPair{}
 	for !p.peekTokenIs(token.RBrace) {
 		p.nextToken()
 		key := p.parseExpression(Lowest)
@@ -254,7 +520,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		}
 		p.nextToken()
 		value := p.parseExpression(Lowest)
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
 			return nil
 		}
@@ -297,13 +563,323 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return &expression
 }
 
+// parseGroupedExpression parses an expression in parentheses, a tuple literal
+// or the parameter list of an arrow function, since they look the same until
+// the '=>' after the closing parenthesis. A tuple has a comma, like `(a, b)`
+// or `(a,)`.
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	lparen := p.curToken
+	if p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		if !p.expectPeek(token.Arrow) {
+			return nil
+		}
+		return p.parseArrowFunction([]*ast.Identifier{})
+	}
 	p.nextToken()
-	exp := p.parseExpression(Lowest)
+	exps := []ast.Expression{p.parseExpression(Lowest)}
+	trailingComma := false
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		if p.peekTokenIs(token.RParen) {
+			trailingComma = true
+			break
+		}
+		p.nextToken()
+		exps = append(exps, p.parseExpression(Lowest))
+	}
 	if !p.expectPeek(token.RParen) {
 		return nil
 	}
-	return exp
+	if p.peekTokenIs(token.Arrow) {
+		p.nextToken()
+		params := make([]*ast.Identifier, len(exps))
+		for i, exp := range exps {
+			ident, ok := exp.(*ast.Identifier)
+			if !ok {
+				msg := fmt.Sprintf("arrow function parameter must be an identifier, got %s", exp)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+			params[i] = ident
+		}
+		return p.parseArrowFunction(params)
+	}
+	if len(exps) > 1 || trailingComma {
+		return &ast.TupleLiteral{
+			Token:    lparen,
+			Elements: exps,
+		}
+	}
+	if call, ok := exps[0].(*ast.CallExpression); ok {
+		call.Parenthesized = true
+	}
+	return exps[0]
+}
+
+// parseArrowFunction desugars `params => body` into an ast.FunctionLiteral.
+// The body is either a block or a single expression. The current token is
+// '=>'.
+func (p *Parser) parseArrowFunction(params []*ast.Identifier) ast.Expression {
+	lit := ast.FunctionLiteral{
+		Token:      token.Token{Type: token.Function, Literal: "fn"},
+		Parameters: params,
+	}
+	lit.Body = p.parseArrowBody()
+	if lit.Body == nil {
+		return nil
+	}
+	return &lit
+}
+
+// parseArrowBody parses what follows a '=>', either a block or a single
+// expression, which is wrapped in a block. The current token is '=>'.
+func (p *Parser) parseArrowBody() *ast.BlockStatement {
+	p.nextToken()
+	if p.curTokenIs(token.LBrace) {
+		return p.parseBlockStatement()
+	}
+	bodyToken := p.curToken
+	body := p.parseExpression(Lowest)
+	if body == nil {
+		return nil
+	}
+	return &ast.BlockStatement{
+		Token: token.Token{Type: token.LBrace, Literal: "{"},
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Token:      bodyToken,
+				Expression: body,
+			},
+		},
+	}
+}
+
+// parseMatchExpression parses
+// `match (value) { pattern if (guard) => body, ... }`, the guards are
+// optional.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := ast.MatchExpression{
+		Token: p.curToken,
+	}
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+	p.nextToken()
+	expression.Value = p.parseExpression(Lowest)
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		arm := ast.MatchArm{
+			Pattern: p.parsePattern(),
+		}
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekTokenIs(token.If) {
+			p.nextToken()
+			if !p.expectPeek(token.LParen) {
+				return nil
+			}
+			p.nextToken()
+			arm.Guard = p.parseExpression(Lowest)
+			if !p.expectPeek(token.RParen) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.Arrow) {
+			return nil
+		}
+		arm.Body = p.parseArrowBody()
+		if arm.Body == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+	return &expression
+}
+
+// parsePattern parses a pattern of a match arm. Patterns reuse the expression
+// nodes they look like, see ast.MatchArm.
+func (p *Parser) parsePattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.Num, token.String, token.True, token.False, token.Null:
+		return p.prefixParseFns[p.curToken.Type]()
+	case token.Minus:
+		if !p.expectPeek(token.Num) {
+			return nil
+		}
+		return &ast.PrefixExpression{
+			Token:    token.Token{Type: token.Minus, Literal: "-"},
+			Operator: "-",
+			Right:    p.parseIntegerLiteral(),
+		}
+	case token.Ident:
+		ident := &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+		if p.peekTokenIs(token.LParen) {
+			p.nextToken()
+			return p.parseConstructorPattern(ident)
+		}
+		return ident
+	case token.LParen:
+		return p.parseTuplePattern()
+	case token.LBracket:
+		return p.parseArrayPattern()
+	case token.LBrace:
+		return p.parseHashPattern()
+	}
+	msg := fmt.Sprintf("unexpected %s in pattern", p.curToken.Literal)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseConstructorPattern parses a struct or an enum variant pattern, e.g.
+// `Circle(r)`, into a call of the constructor with the field patterns as
+// arguments. The current token is '('.
+func (p *Parser) parseConstructorPattern(constructor *ast.Identifier) ast.Expression {
+	call := ast.CallExpression{
+		Token:     p.curToken,
+		Function:  constructor,
+		Arguments: []ast.Expression{},
+	}
+	for !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		arg := p.parsePattern()
+		if arg == nil {
+			return nil
+		}
+		call.Arguments = append(call.Arguments, arg)
+		if !p.peekTokenIs(token.RParen) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+	return &call
+}
+
+// parseTuplePattern parses a tuple pattern, e.g. `(a, _)`. Like tuple
+// literals, it needs a comma, so `(a)` is not a pattern.
+func (p *Parser) parseTuplePattern() ast.Expression {
+	tuple := ast.TupleLiteral{
+		Token:    p.curToken,
+		Elements: []ast.Expression{},
+	}
+	hasComma := false
+	for !p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		el := p.parsePattern()
+		if el == nil {
+			return nil
+		}
+		tuple.Elements = append(tuple.Elements, el)
+		if p.peekTokenIs(token.RParen) {
+			break
+		}
+		if !p.expectPeek(token.Comma) {
+			return nil
+		}
+		hasComma = true
+	}
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+	if len(tuple.Elements) == 0 {
+		p.errors = append(p.errors, "empty tuple pattern")
+		return nil
+	}
+	if !hasComma {
+		msg := fmt.Sprintf("tuple pattern needs a comma: (%s,)", tuple.Elements[0])
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &tuple
+}
+
+func (p *Parser) parseArrayPattern() ast.Expression {
+	array := ast.ArrayLiteral{
+		Token:    p.curToken,
+		Elements: []ast.Expression{},
+	}
+	for !p.peekTokenIs(token.RBracket) {
+		p.nextToken()
+		// a rest pattern can only be the last one:
+		if p.curTokenIs(token.Ellipsis) {
+			spread := &ast.SpreadExpression{Token: p.curToken}
+			if !p.expectPeek(token.Ident) {
+				return nil
+			}
+			spread.Value = p.parsePattern()
+			array.Elements = append(array.Elements, spread)
+			if p.peekTokenIs(token.Comma) {
+				p.nextToken()
+			}
+			break
+		}
+		el := p.parsePattern()
+		if el == nil {
+			return nil
+		}
+		array.Elements = append(array.Elements, el)
+		if !p.peekTokenIs(token.RBracket) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBracket) {
+		return nil
+	}
+	return &array
+}
+
+func (p *Parser) parseHashPattern() ast.Expression {
+	hash := ast.HashLiteral{
+		Token: p.curToken,
+		Pairs: []ast.HashPair{},
+	}
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		key := p.parsePattern()
+		if key == nil {
+			return nil
+		}
+		switch key.(type) {
+		case *ast.Identifier, *ast.ArrayLiteral, *ast.HashLiteral:
+			msg := fmt.Sprintf("hash pattern key must be a literal, got %s", key)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil
+		}
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+	return &hash
 }
 
 func (p *Parser) parseIfExpression() ast.Expression {
@@ -336,6 +912,10 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := ast.FunctionLiteral{
 		Token: p.curToken,
 	}
+	if p.peekTokenIs(token.Ident) {
+		p.nextToken()
+		lit.Name = p.curToken.Literal
+	}
 	if !p.expectPeek(token.LParen) {
 		return nil
 	}
@@ -361,6 +941,9 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	identifiers = append(identifiers, &ident)
 	for p.peekTokenIs(token.Comma) {
 		p.nextToken()
+		if p.peekTokenIs(token.RParen) {
+			break
+		}
 		p.nextToken()
 		ident := ast.Identifier{
 			Token: p.curToken,
@@ -402,6 +985,26 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return &expression
 }
 
+// parsePipeExpression desugars `x |> f(a)` into `f(x, a)`. A right operand
+// that is not a call, or is a call in parentheses like `(f(a))`, is called
+// with the left one as the only argument.
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	p.nextToken()
+	right := p.parseExpression(Pipe)
+	if right == nil {
+		return nil
+	}
+	if call, ok := right.(*ast.CallExpression); ok && !call.Parenthesized {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		return call
+	}
+	return &ast.CallExpression{
+		Token:     token.Token{Type: token.LParen, Literal: "("},
+		Function:  right,
+		Arguments: []ast.Expression{left},
+	}
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := ast.CallExpression{
 		Token:    p.curToken,
@@ -418,11 +1021,14 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 		return list
 	}
 	p.nextToken()
-	list = append(list, p.parseExpression(Lowest))
+	list = append(list, p.parseListElement())
 	for p.peekTokenIs(token.Comma) {
 		p.nextToken()
+		if p.peekTokenIs(end) {
+			break
+		}
 		p.nextToken()
-		list = append(list, p.parseExpression(Lowest))
+		list = append(list, p.parseListElement())
 	}
 	if !p.expectPeek(end) {
 		return nil
@@ -430,6 +1036,18 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 	return list
 }
 
+// parseListElement parses an element of an array literal or an argument of a
+// call, either of which can be spread with `...`.
+func (p *Parser) parseListElement() ast.Expression {
+	if !p.curTokenIs(token.Ellipsis) {
+		return p.parseExpression(Lowest)
+	}
+	spread := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	spread.Value = p.parseExpression(Lowest)
+	return spread
+}
+
 func (p *Parser) curTokenIs(t token.Type) bool {
 	return p.curToken.Type == t
 }
@@ -40,6 +40,29 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatement(t *testing.T) {
+	input := "const x = 5;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	p.CheckParseErrors(t)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement, got=%d\n",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.LetStatement, got=%T", program.Statements[0])
+	}
+	if !stmt.IsConst() {
+		t.Errorf("stmt is not const")
+	}
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong, want=%q, got=%q", input, stmt.String())
+	}
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -180,6 +203,9 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 > 5;", 5, ">", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"a in b;", "a", "in", "b"},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
@@ -325,6 +351,30 @@ func TestOperatorPrecedenceParsint(t *testing.T) {
 	}
 }
 
+func TestNullHandlingParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"null", "null"},
+		{"a ?? b", "(a ?? b)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a?.[1]", "(a?.[1])"},
+		{"a?.[1][2]", "((a?.[1])[2])"},
+		{"a?.[b ?? c] + 1", "((a?.[(b ?? c)]) + 1)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
 func TestIfExpression(t *testing.T) {
 	input := "if (x < y) { x }"
 	l := lexer.New(input)
@@ -451,6 +501,36 @@ func TestFunctionLiteralExpression(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestFunctionStatementParsing(t *testing.T) {
+	input := "fn add(x, y) { x + y; }"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	p.CheckParseErrors(t)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements, got=%d",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.FunctionStatement, got=%T", program.Statements[0])
+	}
+	if stmt.Name.Value != "add" {
+		t.Errorf("stmt.Name.Value not %q, got=%q", "add", stmt.Name.Value)
+	}
+	if stmt.Function.Name != "add" {
+		t.Errorf("stmt.Function.Name not %q, got=%q", "add", stmt.Function.Name)
+	}
+	if len(stmt.Function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong, want 2, got=%d",
+			len(stmt.Function.Parameters))
+	}
+	want := "fn add(x, y) (x + y)"
+	if stmt.String() != want {
+		t.Errorf("stmt.String() wrong, want=%q, got=%q", want, stmt.String())
+	}
+}
+
 func TestFunctionParameterParsting(t *testing.T) {
 	tests := []struct {
 		input string
@@ -477,6 +557,395 @@ func TestFunctionParameterParsting(t *testing.T) {
 	}
 }
 
+func TestArrowFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x => x + 1", "fn(x) (x + 1)"},
+		{"(a, b) => a + b", "fn(a, b) (a + b)"},
+		{"(a) => a", "fn(a) a"},
+		{"() => 1", "fn() 1"},
+		{"(x) => { let y = x * 2; y }", "fn(x) let y = (x * 2);y"},
+		{"map(xs, n => n + 1)", "map(xs, fn(n) (n + 1))"},
+		{"reduce(xs, 0, (a, b) => a + b)", "reduce(xs, 0, fn(a, b) (a + b))"},
+		{"x => y => x + y", "fn(x) fn(y) (x + y)"},
+		{"(a + b) * c", "((a + b) * c)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestArrowFunctionParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"(a, 1) => a", "arrow function parameter must be an identifier, got 1"},
+		{"() + 1", "expected next token to be =>, got + instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestPipeExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x |> f()", "f(x)"},
+		{"x |> f(a, b)", "f(x, a, b)"},
+		{"x |> f", "f(x)"},
+		{"x |> (f(a))", "f(a)(x)"},
+		{"x |> (f)(a)", "f(x, a)"},
+		{"xs |> filter(p) |> map(g) |> sum()", "sum(map(filter(xs, p), g))"},
+		{"a + b |> f(c * d)", "f((a + b), (c * d))"},
+		{"a ?? b |> f()", "f((a ?? b))"},
+		{"x |> fn(y) { y }", "fn(y) y(x)"},
+		{"x |> ns[\"f\"]()", "(ns[f])(x)"},
+		{"let y = x |> f();", "let y = f(x);"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestSpreadParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[...xs]", "[...xs]"},
+		{"[1, ...xs, 2]", "[1, ...xs, 2]"},
+		{"f(...args)", "f(...args)"},
+		{"f(a, ...xs + ys)", "f(a, ...(xs + ys))"},
+		{"[...[1, 2], ...rest(xs)]", "[...[1, 2], ...rest(xs)]"},
+		{"xs |> f(...ys)", "f(xs, ...ys)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestTrailingCommas(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[1, 2,]", "[1, 2]"},
+		{"[\n\t1,\n\t2,\n]", "[1, 2]"},
+		{"f(a, b,)", "f(a, b)"},
+		{"f(...xs,)", "f(...xs)"},
+		{"{\"a\": 1, \"b\": 2,}", "{a:1, b:2}"},
+		{"{\n\t\"a\": 1,\n\t\"b\": [1, 2,],\n}", "{a:1, b:[1, 2]}"},
+		{"fn(x, y,) { x }", "fn(x, y) x"},
+		{"macro(x,) { x }", "macro(x) x"},
+		{"(a, b,) => a", "fn(a, b) a"},
+		{"(a,) => a", "fn(a) a"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestTrailingCommaErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"[,]", "no prefix parse function for , found"},
+		{"f(1,,)", "no prefix parse function for , found"},
+		{"{\"a\": 1,,}", "no prefix parse function for , found"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"match (x) { 1 => \"one\", _ => \"many\" }", "match (x) {1 => one, _ => many}"},
+		{"match (x) { -1 => a, true => b, null => c, }", "match (x) {(-1) => a, true => b, null => c}"},
+		{"match (x) { n if (n > 0) => n, n => -n }", "match (x) {n if (n > 0) => n, n => (-n)}"},
+		{"match (x) { y => { let z = y; z } }", "match (x) {y => let z = y;z}"},
+		{"match (xs) { [] => 0, [a, b,] => a, [h, ...t] => h }", "match (xs) {[] => 0, [a, b] => a, [h, ...t] => h}"},
+		{"match (h) { {\"k\": [v, _], 1: true} => v }", "match (h) {{k:[v, _], 1:true} => v}"},
+		{"let y = match (x + 1) { _ => 0 } * 2;", "let y = (match ((x + 1)) {_ => 0} * 2);"},
+		{"match (x) {}", "match (x) {}"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestMatchExpressionParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"match (x) { a + 1 => 1 }", "expected next token to be =>, got + instead"},
+		{"match (x) { + => 1 }", "unexpected + in pattern"},
+		{"match (x) { (a) => 1 }", "tuple pattern needs a comma: (a,)"},
+		{"match (x) { () => 1 }", "empty tuple pattern"},
+		{"match (x) { n if n > 0 => 1 }", "expected next token to be (, got IDENT instead"},
+		{"match (x) { [...t, h] => 1 }", "expected next token to be ], got IDENT instead"},
+		{"match (x) { [...1] => 1 }", "expected next token to be IDENT, got NUM instead"},
+		{"match (x) { {k: 1} => 1 }", "hash pattern key must be a literal, got k"},
+		{"match (x) { 1 => 1 2 => 2 }", "expected next token to be ,, got NUM instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestMemberAccessParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a.b", "(a[b])"},
+		{"a.b.c", "((a[b])[c])"},
+		{"a.b[0].c", "(((a[b])[0])[c])"},
+		{"a.f(1)", "(a[f])(1)"},
+		{"a?.b", "(a?.[b])"},
+		{"a?.b.c", "((a?.[b])[c])"},
+		{"-a.b", "(-(a[b]))"},
+		{"a.b + c.d", "((a[b]) + (c[d]))"},
+		{"[1].len", "([1][len])"},
+		{"h.match", "(h[match])"},
+		{"h.in.null", "((h[in])[null])"},
+		{"h?.if", "(h?.[if])"},
+		{"h.fn(1)", "(h[fn])(1)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestMemberAccessParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"a.1", "expected next token to be IDENT, got NUM instead"},
+		{"a.", "expected next token to be IDENT, got EOF instead"},
+		{"a?.(1)", "expected next token to be [, got ( instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestStructStatementParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point {\n\tx,\n\ty,\n};", "struct Point { x, y }"},
+		{"struct Unit {}", "struct Unit {}"},
+		{"struct P { x } P(1).x", "struct P { x }(P(1)[x])"},
+		{"struct V { x, fn __add__(o) { V(self.x + o.x) }, y, }", "struct V { x, y, fn __add__(o) V(((self[x]) + (o[x]))) }"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestStructStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"struct { x }", "expected next token to be IDENT, got { instead"},
+		{"struct P x", "expected next token to be {, got IDENT instead"},
+		{"struct P { x y }", "expected next token to be ,, got IDENT instead"},
+		{"struct P { 1 }", "expected next token to be IDENT, got NUM instead"},
+		{"struct P { x, y, x }", "duplicate field x in struct P"},
+		{"struct P { x, fn x() { 1 } }", "duplicate method x in struct P"},
+		{"struct P { fn() { 1 } }", "method in struct P must have a name"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestEnumStatementParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"enum Shape { Circle(r), Rect(w, h) }", "enum Shape { Circle(r), Rect(w, h) }"},
+		{"enum Option {\n\tSome(v,),\n\tNone,\n};", "enum Option { Some(v), None }"},
+		{"enum Unit { U() }", "enum Unit { U }"},
+		{"enum Never {}", "enum Never {}"},
+		{"match (s) { Circle(r) => r, Rect(w, _) if (w > 0) => w, Empty => 0 }",
+			"match (s) {Circle(r) => r, Rect(w, _) if (w > 0) => w, Empty => 0}"},
+		{"match (o) { Some([x, ...xs]) => x, Some(Point(1, y)) => y, None() => 0 }",
+			"match (o) {Some([x, ...xs]) => x, Some(Point(1, y)) => y, None() => 0}"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestEnumStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"enum { A }", "expected next token to be IDENT, got { instead"},
+		{"enum E { A B }", "expected next token to be ,, got IDENT instead"},
+		{"enum E { A(1) }", "expected next token to be IDENT, got NUM instead"},
+		{"enum E { A(x, x) }", "duplicate field x in variant A"},
+		{"enum E { A, B, A(x) }", "duplicate variant A in enum E"},
+		{"match (x) { Some(1 }", "expected next token to be ,, got } instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
+func TestTupleParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"(1, 2)", "(1, 2)"},
+		{"(1,)", "(1,)"},
+		{"(1)", "1"},
+		{"(a + b, c * d,)", "((a + b), (c * d))"},
+		{"((1, 2), (3,))", "((1, 2), (3,))"},
+		{"(1, 2)[0]", "((1, 2)[0])"},
+		{"f((1, 2))", "f((1, 2))"},
+		{"let (q, r) = divmod(7, 2);", "let (q, r) = divmod(7, 2);"},
+		{"const (a, _,) = t;", "const (a, _) = t;"},
+		{"let (_, b, _) = (1, 2, 3);", "let (_, b, _) = (1, 2, 3);"},
+		{"match (t) { (0, y) => y, (x, _) => x }", "match (t) {(0, y) => y, (x, _) => x}"},
+		{"match (t) { (x,) => x }", "match (t) {(x,) => x}"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		p.CheckParseErrors(t)
+		if program.String() != tt.want {
+			t.Errorf("want=%q, got=%q", tt.want, program.String())
+		}
+	}
+}
+
+func TestLetUnpackingParsingErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"let (a, 1) = t;", "expected next token to be IDENT, got NUM instead"},
+		{"let (a, a) = t;", "duplicate name a in let"},
+		{"let (_, a, a) = t;", "duplicate name a in let"},
+		{"const (a b) = t;", "expected next token to be ,, got IDENT instead"},
+		{"let (a, b);", "expected next token to be =, got ; instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.wantErr {
+			t.Errorf("%s: want error %q, got=%q", tt.input, tt.wantErr, errors)
+		}
+	}
+}
+
 func TestCallExpression(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5)"
 	l := lexer.New(input)
@@ -609,13 +1078,25 @@ func TestParsingHashLiteralStringKeys(t *testing.T) {
 		"two":   2,
 		"three": 3,
 	}
-	for key, value := range hash.Pairs {
-		literal, ok := key.(*ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not *ast.StringLiteral, got=%T", key)
+			t.Errorf("key is not *ast.StringLiteral, got=%T", pair.Key)
 		}
 		wantValue := want[literal.String()]
-		testIntegerLiteral(t, value, wantValue)
+		testIntegerLiteral(t, pair.Value, wantValue)
+	}
+}
+
+func TestParsingHashLiteralOrder(t *testing.T) {
+	input := `{"c": 3, "a": 1 + 1, "b": 2}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	p.CheckParseErrors(t)
+	want := "{c:3, a:(1 + 1), b:2}"
+	if program.String() != want {
+		t.Errorf("wrong hash literal, want=%q, got=%q", want, program.String())
 	}
 }
 
@@ -660,17 +1141,17 @@ func TestParsingHashLiteralWithExpressions(t *testing.T) {
 			testInfixExpression(t, e, 15, "/", 5)
 		},
 	}
-	for key, value := range hash.Pairs {
-		literal, ok := key.(*ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not *ast.StringLiteral, got=%T", key)
+			t.Errorf("key is not *ast.StringLiteral, got=%T", pair.Key)
 		}
 		testFunc, ok := tests[literal.String()]
 		if !ok {
 			t.Errorf("no test function for key %q found", literal.String())
 			continue
 		}
-		testFunc(value)
+		testFunc(pair.Value)
 	}
 }
 
@@ -1,7 +1,5 @@
 
-let mapped = map([2, 4, 6], fn(n) {
-    n + 1
-});
+let mapped = map([2, 4, 6], n => n + 1);
 
 let summed = sum([1, 2, 3, 4, 5]);
 
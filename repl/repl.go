@@ -25,6 +25,7 @@ var stdlibFiles = []string{
 func Start(in io.Reader, out io.Writer, prompt string) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnv()
+	env.SetOut(out)
 	macroEnv := object.NewEnv()
 	for {
 		fmt.Printf(prompt)
@@ -40,7 +41,10 @@ func Start(in io.Reader, out io.Writer, prompt string) {
 			printParserErrors(out, p.Errors())
 			continue
 		}
-		evaluator.DefineMacros(program, macroEnv)
+		if err := evaluator.DefineMacros(program, macroEnv); err != nil {
+			printMacroError(out, err)
+			continue
+		}
 		expandedProgram := evaluator.ExpandMacros(program, macroEnv)
 		evaluated := evaluator.Eval(expandedProgram, env)
 		if evaluated != nil {
@@ -53,6 +57,7 @@ func Start(in io.Reader, out io.Writer, prompt string) {
 // DoFile interprets a program from a given Reader.
 func DoFile(in io.Reader, out io.Writer) error {
 	env := object.NewEnv()
+	env.SetOut(out)
 	macroEnv := object.NewEnv()
 	if err := doStdlib(stdlibFiles, out, env, macroEnv); err != nil {
 		return err
@@ -85,7 +90,10 @@ func doFile(in io.Reader, out io.Writer, env, macroEnv *object.Env) error {
 		printParserErrors(out, p.Errors())
 		return errors.New("TODO")
 	}
-	evaluator.DefineMacros(program, macroEnv)
+	if err := evaluator.DefineMacros(program, macroEnv); err != nil {
+		printMacroError(out, err)
+		return err
+	}
 	expandedProgram := evaluator.ExpandMacros(program, macroEnv)
 	evaluated := evaluator.Eval(expandedProgram, env)
 	if evaluated != nil {
@@ -100,3 +108,7 @@ func printParserErrors(out io.Writer, errors []string) {
 		io.WriteString(out, "\t"+msg+"\n")
 	}
 }
+
+func printMacroError(out io.Writer, err error) {
+	io.WriteString(out, "ERROR: "+err.Error()+"\n")
+}
@@ -1,5 +1,5 @@
 
-let unless = macro(condition, consequence, alternative) {
+const unless = macro(condition, consequence, alternative) {
     quote(if (!(unquote(condition))) {
         unquote(consequence);
     } else {
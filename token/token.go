@@ -19,8 +19,16 @@ const (
 	Slash     = "/"
 	LT        = "<"
 	GT        = ">"
+	LTE       = "<="
+	GTE       = ">="
 	Equals    = "=="
 	NotEquals = "!="
+	Coalesce  = "??"
+	OptChain  = "?."
+	Arrow     = "=>"
+	Pipe      = "|>"
+	Ellipsis  = "..."
+	Dot       = "."
 
 	// Delimiters
 	Comma     = ","
@@ -42,6 +50,12 @@ const (
 	Else     = "ELSE"
 	Return   = "RETURN"
 	Macro    = "MACRO"
+	In       = "IN"
+	Null     = "NULL"
+	Const    = "CONST"
+	Match    = "MATCH"
+	Struct   = "STRUCT"
+	Enum     = "ENUM"
 )
 
 var keywords = map[string]Type{
@@ -53,6 +67,12 @@ var keywords = map[string]Type{
 	"else":   Else,
 	"return": Return,
 	"macro":  Macro,
+	"in":     In,
+	"null":   Null,
+	"const":  Const,
+	"match":  Match,
+	"struct": Struct,
+	"enum":   Enum,
 }
 
 // Type identifies a token type.
@@ -72,3 +92,13 @@ func LookupIdent(ident string) Type {
 	}
 	return Ident
 }
+
+// IsKeyword reports whether t is the type of a reserved keyword.
+func IsKeyword(t Type) bool {
+	for _, tokType := range keywords {
+		if tokType == t {
+			return true
+		}
+	}
+	return false
+}